@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of the environment variables setting the flags
+// and configuration keys.
+const envPrefix = "GOTAGLOG"
+
+// configKey is a configuration key without flag.
+type configKey struct {
+	name  string
+	usage string
+	// json is set for the keys holding lists or maps, whose environment
+	// variable is decoded as JSON.
+	json bool
+}
+
+// configKeys are the configuration keys without flag, which can also be set
+// with environment variables.
+var configKeys = []configKey{
+	{"groups", `commit groups, as a JSON list of {"message", "group", "skip"} objects`, true},
+	{"types", "group titles by conventional commit type, as a JSON object", true},
+	{"emoji", "leading emoji of the group titles by type, as a JSON object", true},
+	{"authors", `handles of the authors, as a JSON list of {"email", "handle"} objects`, true},
+	{"skip_patterns", "regular expressions of the commit titles to leave out, as a JSON list", true},
+	{"breaking_keywords", "additional breaking change footer keywords, as a JSON list", true},
+	{"breaking_keywords_replace", "replace the built-in breaking change keywords with breaking_keywords", false},
+}
+
+// envName returns the name of the environment variable setting the flag or
+// configuration key.
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// bindConfigEnv binds the configuration keys without flag to their
+// environment variables, decoding the JSON of the lists and maps.
+func bindConfigEnv() error {
+	for _, key := range configKeys {
+		name := envName(key.name)
+		if err := viper.BindEnv(key.name, name); err != nil {
+			return err
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok || !key.json {
+			continue
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		viper.Set(key.name, decoded)
+	}
+	return nil
+}
+
+// actionFlags are the flags acting on the command rather than configuring
+// the changelog: they are neither read from the environment nor part of
+// the effective configuration.
+var actionFlags = map[string]bool{
+	"config":       true,
+	"help":         true,
+	"print-config": true,
+	"print-env":    true,
+}
+
+// printEnv writes the environment variables setting the flags of the
+// command and the configuration keys to stdout, along with their usage,
+// sorted by name.
+func printEnv(cmd *cobra.Command) error {
+	usages := make(map[string]string)
+	addFlag := func(flag *pflag.Flag) {
+		if !actionFlags[flag.Name] {
+			usages[envName(flag.Name)] = flag.Usage
+		}
+	}
+	cmd.PersistentFlags().VisitAll(addFlag)
+	cmd.Flags().VisitAll(addFlag)
+	for _, key := range configKeys {
+		usages[envName(key.name)] = key.usage
+	}
+
+	names := make([]string, 0, len(usages))
+	for name := range usages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, usages[name])
+	}
+	return w.Flush()
+}
+
+// printConfig writes the effective configuration, merged from the flags,
+// environment variables and configuration file, to stdout as YAML.
+func printConfig() error {
+	settings := viper.AllSettings()
+	for name := range actionFlags {
+		delete(settings, name)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	err := encoder.Encode(settings)
+	if err != nil {
+		return fmt.Errorf("cannot encode configuration: %w", err)
+	}
+	return encoder.Close()
+}
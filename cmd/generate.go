@@ -1,142 +1,314 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/Masterminds/semver"
+	"github.com/alecthomas/chroma/v2/quick"
 	"github.com/charmbracelet/glamour"
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/frgrisk/gotaglog/pkg/changelog"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"golang.org/x/term"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
-type CommitGroup struct {
-	Message string
-	Group   string
-	Skip    bool
+const (
+	// formatMarkdown outputs the changelog as Markdown.
+	formatMarkdown = "markdown"
+	// formatHTML outputs the changelog as HTML.
+	formatHTML = "html"
+	// formatJSON outputs the data model of the changelog as JSON.
+	formatJSON = "json"
+	// formatAtom outputs the releases as an Atom feed.
+	formatAtom = "atom"
+)
+
+// loadCommitGroups returns the commit groups defined under the "groups"
+// key of the configuration file, or nil to use the built-in groups.
+func loadCommitGroups() ([]changelog.CommitGroup, error) {
+	if !viper.IsSet("groups") {
+		return nil, nil
+	}
+	groups := []changelog.CommitGroup{}
+	err := viper.UnmarshalKey("groups", &groups)
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
 }
 
-var commitGroups = []CommitGroup{
-	{Message: "^feat", Group: "✨ Features"},
-	{Message: "^fix", Group: "🐛 Fixes"},
-	{Message: "^docs", Group: "📖 Documentation"},
-	{Message: "^perf", Group: "⚡️Performance"},
-	{Message: "^refactor", Group: "✏️ Refactor"},
-	{Message: "^revert", Group: "↩️ Revert"},
-	{Message: "^style", Group: "Styling"},
-	{Message: "^test", Group: "🧪 Testing"},
-	{Message: "^build\\(deps\\)", Group: "⚙️ Dependencies"},
-	{Message: "^build\\(deps-dev\\)", Group: "⚙️ Dev Dependencies"},
-	{Message: "^build", Group: "🛠️ Build System"},
-	{Message: "^ci", Group: "🔄 Continuous Integration"},
-	{Message: "^chore\\(release\\)", Skip: true},
-	{Message: "^chore\\(ignore\\)", Skip: true},
-	{Message: "^chore", Group: "Miscellaneous Tasks"},
+// authorHandle maps an author email to a handle in the configuration.
+type authorHandle struct {
+	Email  string `mapstructure:"email"`
+	Handle string `mapstructure:"handle"`
 }
 
-func getChangeLog() {
-	repoPath := viper.GetString("repo")
-	if repoPath == "" {
-		log.Fatalln("Repository path is empty")
-		return
-	}
-	repoPath = filepath.Clean(repoPath)
-	log.Debugf("Repository path is set to %q", repoPath)
-	repo, err := git.PlainOpen(repoPath)
+// loadAuthorHandles returns the handles of the authors defined under the
+// "authors" key of the configuration file, by email.
+func loadAuthorHandles() (map[string]string, error) {
+	var authors []authorHandle
+	err := viper.UnmarshalKey("authors", &authors)
 	if err != nil {
-		log.Fatalln("Cannot open repository:", err)
-		return
+		return nil, err
+	}
+	handles := make(map[string]string, len(authors))
+	for _, author := range authors {
+		handles[author.Email] = author.Handle
 	}
+	return handles, nil
+}
 
-	tags, err := repo.Tags()
+// getOptions maps the flags and configuration to changelog options.
+func getOptions() (changelog.Options, error) {
+	groups, err := loadCommitGroups()
 	if err != nil {
-		log.Fatalln("Cannot fetch tags:", err)
-		return
+		return changelog.Options{}, fmt.Errorf("cannot load commit groups: %w", err)
+	}
+	authorHandles, err := loadAuthorHandles()
+	if err != nil {
+		return changelog.Options{}, fmt.Errorf("cannot load author handles: %w", err)
 	}
 
-	var semverTags semver.Collection
-	tagMap := make(map[string]*plumbing.Reference)
+	dateFormat := viper.GetString("date-format")
+	if time.Date(1999, time.December, 31, 23, 59, 58, 0, time.UTC).Format(dateFormat) == dateFormat {
+		log.Warnf("Date format %q does not contain any time layout elements.", dateFormat)
+	}
 
-	err = tags.ForEach(func(tag *plumbing.Reference) error {
-		ver, err := semver.NewVersion(tag.Name().Short())
-		if err == nil {
-			semverTags = append(semverTags, ver)
-			tagMap[ver.String()] = tag
+	var catchAll string
+	if viper.GetBool("catch-all") {
+		catchAll = viper.GetString("catch-all-title")
+	}
+
+	var tmpl string
+	if path := viper.GetString("template"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return changelog.Options{}, fmt.Errorf("cannot read template: %w", err)
 		}
-		return nil
-	})
-	if err != nil {
-		log.Fatalln("Cannot iterate tags:", err)
-		return
+		tmpl = string(b)
 	}
 
-	sort.Sort(semverTags)
+	var keyring string
+	if path := viper.GetString("keyring"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return changelog.Options{}, fmt.Errorf("cannot read keyring: %w", err)
+		}
+		keyring = string(b)
+	}
 
-	var prevTag *plumbing.Reference
+	repos := viper.GetStringSlice("repo")
+	if len(repos) == 0 {
+		return changelog.Options{}, errors.New("no repository given")
+	}
 
-	var changelog []string
+	return changelog.Options{
+		RepoPath:                repos[0],
+		RepoPaths:               repos,
+		Groups:                  groups,
+		AllowLeadingEmoji:       viper.GetBool("allow-leading-emoji"),
+		SkipPatterns:            viper.GetStringSlice("skip_patterns"),
+		Types:                   viper.GetStringMapString("types"),
+		UnreleasedTag:           viper.GetString("tag"),
+		IncMajor:                viper.GetBool("inc-major"),
+		IncMinor:                viper.GetBool("inc-minor"),
+		IncPatch:                viper.GetBool("inc-patch"),
+		IncAuto:                 viper.GetBool("inc-auto"),
+		DateRange:               viper.GetBool("date-range"),
+		Branch:                  viper.GetString("branch"),
+		AllBranches:             viper.GetBool("all-branches"),
+		UnreleasedOnly:          viper.GetBool("unreleased"),
+		NoUnreleased:            viper.GetBool("no-unreleased"),
+		CommitOrder:             viper.GetString("commit-order"),
+		Capitalize:              viper.GetString("capitalize"),
+		Language:                viper.GetString("language"),
+		MaxPerGroup:             viper.GetInt("max-per-group"),
+		BreakingPosition:        viper.GetString("breaking-position"),
+		TagPrefix:               viper.GetString("tag-prefix"),
+		TagPattern:              viper.GetString("tag-pattern"),
+		Component:               viper.GetString("component"),
+		SkipPrerelease:          viper.GetBool("skip-prerelease"),
+		Since:                   viper.GetString("since"),
+		Until:                   viper.GetString("until"),
+		Limit:                   viper.GetInt("limit"),
+		LimitExcludesUnreleased: viper.GetBool("limit-excludes-unreleased"),
+		FromRef:                 viper.GetString("from-ref"),
+		ToRef:                   viper.GetString("to-ref"),
+		Order:                   viper.GetString("order"),
+		DateFormat:              dateFormat,
+		Timezone:                viper.GetString("timezone"),
+		DateSource:              viper.GetString("date-source"),
+		SortBy:                  viper.GetString("sort-by"),
+		Paths:                   viper.GetStringSlice("path"),
+		Stats:                   viper.GetBool("stats"),
+		Title:                   viper.GetString("title"),
+		NoTitle:                 viper.IsSet("title") && viper.GetString("title") == "",
+		TOC:                     viper.GetBool("toc"),
+		Strict:                  viper.GetBool("strict"),
+		ReportSkipped:           viper.GetBool("verbose"),
+		Progress:                progressReporter(),
+		ShowEmptyGroups:         viper.GetBool("show-empty-groups"),
+		Emoji:                   viper.GetStringMapString("emoji"),
+		CatchAll:                catchAll,
+		FoldReverts:             viper.GetBool("fold-reverts"),
+		IncludeMerges:           !viper.GetBool("skip-merges"),
+		IncludeTagMessage:       viper.GetBool("include-tag-message"),
+		ExcludeAuthors:          viper.GetStringSlice("exclude-author"),
+		BreakingKeywords:        viper.GetStringSlice("breaking_keywords"),
+		ReplaceBreakingKeywords: viper.GetBool("breaking_keywords_replace"),
+		Compact:                 viper.GetBool("compact"),
+		IncludeBody:             viper.GetBool("include-body"),
+		BreakingNotes:           viper.GetBool("breaking-notes"),
+		Scopes:                  viper.GetStringSlice("scope"),
+		RequireScope:            viper.GetBool("require-scope"),
+		ExcludeTypes:            viper.GetStringSlice("exclude-type"),
+		IncludeTypes:            viper.GetStringSlice("include-type"),
+		KeepDuplicates:          !viper.GetBool("dedupe"),
+		GroupByScope:            viper.GetBool("group-by-scope"),
+		NoEmoji:                 viper.GetBool("no-emoji"),
+		ShowCounts:              viper.GetBool("show-counts"),
+		CountAll:                viper.GetBool("count-all"),
+		ShowAuthors:             viper.GetBool("show-authors"),
+		ShowReviewers:           viper.GetBool("show-reviewers"),
+		AuthorHandles:           authorHandles,
+		GitHubHandles:           viper.GetBool("github-handles"),
+		VerifySignatures:        viper.GetBool("verify-signatures"),
+		Keyring:                 keyring,
+		ShowHash:                viper.GetBool("show-hash"),
+		ShowIssues:              viper.GetBool("show-issues"),
+		RepoURL:                 viper.GetString("repo-url"),
+		CommitPath:              viper.GetString("commit-path"),
+		IssueURL:                viper.GetString("issue-url"),
+		PRURL:                   viper.GetString("pr-url"),
+		CompareLinks:            viper.GetBool("compare-links"),
+		ComparePath:             viper.GetString("compare-path"),
+		ReleasePath:             viper.GetString("release-path"),
+		Template:                tmpl,
+	}, nil
+}
 
-	for _, ver := range semverTags {
-		tag := tagMap[ver.String()]
-		entry := fmt.Sprintf("## [%s] - %s\n", ver.String(), getTagCommit(repo, tag).Author.When.Format("2006-01-02"))
-		entry += getTagEntryDetails(repo, prevTag, tag)
-		changelog = append([]string{entry}, changelog...)
-		prevTag = tag
-		if ver == semverTags[len(semverTags)-1] {
-			entry = getTagEntryDetails(repo, tag, nil)
-			unreleasedTag := viper.GetString("tag")
-			unreleasedHeader := fmt.Sprintf("## [%s]", unreleasedTag)
-			if viper.GetBool("inc-major") {
-				unreleasedVer := ver.IncMajor()
-				unreleasedHeader = fmt.Sprintf("## [%s] - %s", &unreleasedVer, time.Now().Format("2006-01-02"))
-			} else if viper.GetBool("inc-minor") {
-				unreleasedVer := ver.IncMinor()
-				unreleasedHeader = fmt.Sprintf("## [%s] - %s", &unreleasedVer, time.Now().Format("2006-01-02"))
-			} else if viper.GetBool("inc-patch") {
-				unreleasedVer := ver.IncPatch()
-				unreleasedHeader = fmt.Sprintf("## [%s] - %s", &unreleasedVer, time.Now().Format("2006-01-02"))
-			} else if unreleasedTag != defaultUnreleasedTag {
-				unreleasedVer, err := semver.NewVersion(unreleasedTag)
-				if err != nil {
-					log.WithField("tag", unreleasedTag).Fatal(err)
-				}
-				if unreleasedVer.LessThan(ver) {
-					log.Warnf("Unreleased tag %q is lower than existing tag %q in the repository.", unreleasedVer, ver)
-				}
-				if unreleasedVer.Equal(ver) {
-					log.Warnf("Unreleased tag %q already exists in the repository.", unreleasedVer)
-				}
-				unreleasedHeader = fmt.Sprintf("## [%s] - %s", unreleasedVer, time.Now().Format("2006-01-02"))
-			}
-			unreleasedEntry := []string{unreleasedHeader, entry}
-			if entry != "" {
-				if viper.GetBool("unreleased") {
-					changelog = unreleasedEntry
-				} else {
-					changelog = append(unreleasedEntry, changelog...)
-				}
+// getChangeLog builds the changelog with the options and writes it in the
+// output format and destination of the flags.
+func getChangeLog(opts changelog.Options) error {
+	format := viper.GetString("format")
+	if format != formatMarkdown && format != formatHTML && format != formatJSON && format != formatAtom {
+		return fmt.Errorf("invalid format %q: must be %q, %q, %q or %q", format, formatMarkdown, formatHTML, formatJSON, formatAtom)
+	}
+	if viper.GetBool("merge") {
+		if viper.GetString("output") == "" {
+			return errors.New("merge requires an output file")
+		}
+		if format != formatMarkdown {
+			return fmt.Errorf("merge requires the %q format", formatMarkdown)
+		}
+	}
+	if viper.GetBool("insert") {
+		if viper.GetString("output") == "" {
+			return errors.New("insert requires an output file")
+		}
+		if format != formatMarkdown {
+			return fmt.Errorf("insert requires the %q format", formatMarkdown)
+		}
+		if viper.GetBool("merge") {
+			return errors.New("insert cannot be combined with merge")
+		}
+	}
+
+	if viper.GetString("split-output") != "" {
+		if viper.GetString("output") != "" {
+			return errors.New("split output cannot be combined with an output file")
+		}
+		if format != formatMarkdown {
+			return fmt.Errorf("split output requires the %q format", formatMarkdown)
+		}
+	}
+
+	cl, err := changelog.Build(opts)
+	if err != nil {
+		return fmt.Errorf("cannot generate changelog: %w", err)
+	}
+	if opts.ReportSkipped {
+		printSkipped(cl)
+	}
+
+	if viper.GetBool("fail-if-no-unreleased") {
+		// Only the exit status tells whether there are unreleased changes.
+		for _, version := range cl.Versions {
+			if version.Unreleased {
+				return nil
 			}
 		}
+		return errors.New("no unreleased changes")
+	}
+	if viper.GetBool("dry-run") {
+		printSummary(cl)
+		return nil
+	}
+	if dir := viper.GetString("split-output"); dir != "" {
+		return writeSplitChangelog(cl, dir)
 	}
-	changelog = append([]string{"# Changelog\n"}, changelog...)
+
+	var md string
+	switch format {
+	case formatJSON:
+		md, err = encodeJSON(cl, viper.GetBool("pretty"))
+	case formatAtom:
+		md, err = changelog.ToAtom(cl, viper.GetString("feed-url"))
+	default:
+		md, err = cl.Render()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot generate changelog: %w", err)
+	}
+	if format == formatHTML {
+		md, err = changelog.ToHTML(md)
+		if err != nil {
+			return err
+		}
+	}
+
 	if viper.GetString("output") != "" {
-		err = os.WriteFile(viper.GetString("output"), []byte(strings.Join(changelog, "\n")), 0644)
+		content := md
+		if viper.GetBool("merge") || viper.GetBool("insert") {
+			existing, err := os.ReadFile(viper.GetString("output"))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("cannot read existing changelog: %w", err)
+			}
+			unreleased := []string{opts.UnreleasedTag}
+			for _, version := range cl.Versions {
+				if version.Unreleased {
+					unreleased = append(unreleased, version.Label)
+				}
+			}
+			if viper.GetBool("merge") {
+				content = mergeChangelogs(string(existing), md, unreleased...)
+			} else if inserted, ok := insertChangelog(string(existing), md, unreleased...); ok {
+				content = inserted
+			} else {
+				log.Warnf("Output file %q has no %s marker, overwriting it.", viper.GetString("output"), insertMarker)
+			}
+		}
+		err = os.WriteFile(viper.GetString("output"), []byte(content), 0644)
 		if err != nil {
-			log.Fatalln("Cannot write to file:", err)
+			return fmt.Errorf("cannot write to file: %w", err)
 		}
-		return
+		if !viper.GetBool("tee") {
+			return nil
+		}
+	}
+
+	if format == formatJSON && viper.GetBool("pretty") && !viper.GetBool("plain") && term.IsTerminal(int(os.Stdout.Fd())) {
+		return quick.Highlight(os.Stdout, md, "json", "terminal256", "monokai")
+	}
+	if viper.GetBool("plain") || format != formatMarkdown {
+		fmt.Print(md)
+		return nil
 	}
 
 	// initialize glamour
@@ -147,10 +319,22 @@ func getChangeLog() {
 	if !isTerminal {
 		style = "notty"
 	}
+	if viper.GetString("style") != "" {
+		style = viper.GetString("style")
+		if err := checkStyle(style); err != nil {
+			return err
+		}
+	}
 
-	// Detect terminal width
+	// Detect terminal width, unless the width is given
 	var width uint
-	if isTerminal {
+	if viper.IsSet("width") {
+		w := viper.GetInt("width")
+		if w < 0 {
+			return fmt.Errorf("invalid width %d: must not be negative", w)
+		}
+		width = uint(w)
+	} else if isTerminal {
 		w, _, err := term.GetSize(int(os.Stdout.Fd()))
 		if err == nil {
 			width = uint(w)
@@ -160,7 +344,7 @@ func getChangeLog() {
 			width = 120
 		}
 	}
-	if width == 0 {
+	if width == 0 && !viper.IsSet("width") {
 		width = 80
 	}
 
@@ -177,114 +361,95 @@ func getChangeLog() {
 		glamour.WithPreservedNewLines(),
 	)
 	if err != nil {
-		log.Fatalln("Cannot create terminal renderer:", err)
+		return fmt.Errorf("cannot create terminal renderer: %w", err)
 	}
 
-	out, err := r.Render(strings.Join(changelog, "\n"))
+	out, err := r.Render(md)
 	if err != nil {
-		log.Fatalln("Cannot render changelog:", err)
+		return fmt.Errorf("cannot render changelog: %w", err)
 	}
 	fmt.Print(out)
+	return nil
 }
 
-func getTagEntryDetails(repo *git.Repository, olderTag, newerTag *plumbing.Reference) string {
-	var from, until *object.Commit
-	options := &git.LogOptions{}
-
-	if olderTag != nil {
-		from = getTagCommit(repo, olderTag)
-	}
-
-	if newerTag != nil {
-		until = getTagCommit(repo, newerTag)
-		options = &git.LogOptions{From: until.Hash}
+// encodeJSON returns the data model of the changelog as JSON, indented if
+// pretty is set.
+func encodeJSON(cl *changelog.Changelog, pretty bool) (string, error) {
+	var b []byte
+	var err error
+	if pretty {
+		b, err = json.MarshalIndent(cl, "", "  ")
+	} else {
+		b, err = json.Marshal(cl)
 	}
-
-	var entry string
-
-	commitIter, err := repo.Log(options)
 	if err != nil {
-		log.Fatalln("Cannot fetch commits:", err)
+		return "", fmt.Errorf("cannot encode changelog as JSON: %w", err)
 	}
+	return string(b) + "\n", nil
+}
 
-	groupedCommits := make(map[string][]string)
+// checkStyle returns an error if the style is neither a glamour style nor
+// an existing style file.
+func checkStyle(style string) error {
+	if _, ok := styles.DefaultStyles[style]; ok || style == styles.AutoStyle {
+		return nil
+	}
+	if _, err := os.Stat(style); err == nil {
+		return nil
+	}
+	names := []string{styles.AutoStyle}
+	for name := range styles.DefaultStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("invalid style %q: must be one of %s, or the path to a JSON style file", style, strings.Join(names, ", "))
+}
 
-	_ = commitIter.ForEach(func(c *object.Commit) error {
-		ancestor := false
-		if olderTag != nil {
-			ancestor, err = c.IsAncestor(getTagCommit(repo, olderTag))
-			if err != nil {
-				log.Fatalf("Cannot check ancestor of commit %s: %v", getTagCommit(repo, olderTag).Hash, err)
-			}
+// printSummary writes where the changelog would be written, along with its
+// number of versions and entries, to stderr.
+func printSummary(cl *changelog.Changelog) {
+	var entries int
+	for _, version := range cl.Versions {
+		if version.Breaking != nil {
+			entries += len(version.Breaking.Commits)
 		}
-		if (from != nil && c.Hash == from.Hash) || ancestor {
-			return storer.ErrStop
+		for _, group := range version.Groups {
+			entries += len(group.Commits)
 		}
+	}
 
-		// Only print the first line of the commit message (the title)
-		title := strings.Split(c.Message, "\n")[0]
-
-		for _, group := range commitGroups {
-			re := regexp.MustCompile(group.Message + "(\\(.*\\))?!?:.")
-			matches := re.FindStringSubmatch(title)
-
-			if len(matches) > 0 {
-				if group.Skip {
-					break
-				}
-
-				var scope string
-				if len(matches) > 1 && matches[1] != "" {
-					// Remove the parentheses from the captured scope
-					rawScope := strings.TrimSuffix(strings.TrimPrefix(matches[1], "("), ")")
-					scope = fmt.Sprintf("(**%s**)", strings.ToLower(rawScope))
-				}
-
-				// Remove prefix from the title
-				cleanTitle := re.ReplaceAllString(title, "")
-				words := strings.Fields(cleanTitle)
-				words[0] = cases.Title(language.Und, cases.NoLower).String(words[0])
-				groupedCommits[group.Group] = append(groupedCommits[group.Group], strings.TrimSpace(strings.Join(append([]string{scope}, words...), " ")))
-				break
-			}
-		}
+	output := viper.GetString("output")
+	if output == "" {
+		output = "stdout"
+	}
+	fmt.Fprintf(os.Stderr, "Would write %d versions with %d entries to %s\n", len(cl.Versions), entries, output)
+}
 
-		return nil
+// printSkipped writes the commits left out of the changelog to stderr,
+// grouped by reason, one per line as tab-separated reason, hash and title.
+func printSkipped(cl *changelog.Changelog) {
+	skipped := append([]*changelog.SkippedCommit(nil), cl.Skipped...)
+	sort.SliceStable(skipped, func(i, j int) bool {
+		return skipped[i].Reason < skipped[j].Reason
 	})
-
-	for _, groupName := range commitGroups {
-		commits := groupedCommits[groupName.Group]
-		if len(commits) > 0 {
-			entry += fmt.Sprintf("\n### %s\n\n", groupName.Group)
-			for _, commit := range commits {
-				entry += fmt.Sprintln("- " + commit)
-			}
-		}
+	for _, c := range skipped {
+		fmt.Fprintf(os.Stderr, "%s\t%s\t%s\n", c.Reason, c.Hash, c.Title)
 	}
-	return entry
 }
 
-func getTagCommit(repo *git.Repository, tag *plumbing.Reference) *object.Commit {
-	var commit *object.Commit
-	// Step 1: Resolve the Tag to a Commit
-	// Dereference the tag to get the commit it is pointing to
-	obj, err := repo.TagObject(tag.Hash())
-	if err != nil {
-		// The tag might be a lightweight tag,
-		// not an annotated tag. In this case,
-		// it directly points to a commit.
-		commit, err = repo.CommitObject(tag.Hash())
-		if err != nil {
-			log.Fatalln("Cannot retrieve commit from tag:", err)
-		}
-	} else {
-		// The tag is an annotated tag, so we need to
-		// further resolve the object it is pointing to.
-		commit, err = obj.Commit()
-		if err != nil {
-			log.Fatalln("Cannot retrieve commit from tag object:", err)
+// progressReporter returns a function showing the progress of the
+// generation on a single line of stderr, cleared once a stage ends, or nil
+// if stderr is not a terminal, so that the output is never affected, or
+// with --quiet.
+func progressReporter() func(stage string, done, total int) {
+	if viper.GetBool("quiet") || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return func(stage string, done, total int) {
+		if done >= total {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+			return
 		}
+		fmt.Fprintf(os.Stderr, "\r\033[K%s %d/%d", stage, done+1, total)
 	}
-
-	return commit
 }
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd reports the commit messages that do not follow the conventional
+// commit format.
+var lintCmd = &cobra.Command{
+	Use:   "lint [from-ref [to-ref]]",
+	Short: "Report the commit messages not following the conventional commit format",
+	Long: `Report the commit messages reachable from to-ref but not from from-ref
+that do not follow the conventional commit format, one line per violation
+with the abbreviated hash and the line of the message. It fails if any
+violation is found. The range defaults to the whole history up to HEAD.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		opts, err := getOptions()
+		if err != nil {
+			return err
+		}
+		opts.FromRef, opts.ToRef = "", ""
+		if len(args) > 0 {
+			opts.FromRef = args[0]
+		}
+		if len(args) > 1 {
+			opts.ToRef = args[1]
+		}
+
+		violations, err := changelog.Lint(opts)
+		if err != nil {
+			return fmt.Errorf("cannot lint commits: %w", err)
+		}
+		for _, v := range violations {
+			fmt.Println(v)
+		}
+		if len(violations) == 1 {
+			return errors.New("found 1 violation of the conventional commit format")
+		}
+		if len(violations) > 1 {
+			return fmt.Errorf("found %d violations of the conventional commit format", len(violations))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// versionHeaderRegex matches a version header and captures its label.
+	versionHeaderRegex = regexp.MustCompile(`^## \[([^\]]+)\]`)
+	// linkDefRegex matches a link reference definition and captures its
+	// label.
+	linkDefRegex = regexp.MustCompile(`^\[([^\]]+)\]: `)
+)
+
+// markdownChangelog is a Markdown changelog split into version sections.
+type markdownChangelog struct {
+	// head are the lines before the first version header.
+	head []string
+	// sections are the version sections, in order.
+	sections []markdownSection
+	// links are the trailing link reference definitions.
+	links []string
+}
+
+// markdownSection is the section of a version, from its header to the next
+// version header.
+type markdownSection struct {
+	label string
+	lines []string
+}
+
+// parseMarkdownChangelog splits the changelog into version sections.
+func parseMarkdownChangelog(md string) markdownChangelog {
+	var doc markdownChangelog
+	for _, line := range strings.Split(md, "\n") {
+		if match := versionHeaderRegex.FindStringSubmatch(line); match != nil {
+			doc.sections = append(doc.sections, markdownSection{label: match[1], lines: []string{line}})
+		} else if len(doc.sections) > 0 {
+			last := &doc.sections[len(doc.sections)-1]
+			last.lines = append(last.lines, line)
+		} else {
+			doc.head = append(doc.head, line)
+		}
+	}
+
+	// The link reference definitions follow the last section.
+	tail := &doc.head
+	if len(doc.sections) > 0 {
+		tail = &doc.sections[len(doc.sections)-1].lines
+	}
+	for len(*tail) > 0 {
+		line := (*tail)[len(*tail)-1]
+		if line != "" && !linkDefRegex.MatchString(line) {
+			break
+		}
+		if line != "" {
+			doc.links = append([]string{line}, doc.links...)
+		}
+		*tail = (*tail)[:len(*tail)-1]
+	}
+	return doc
+}
+
+// mergeChangelogs returns the existing changelog with the sections of the
+// generated changelog for the versions it lacks inserted above its first
+// version, along with their links. The sections labeled with one of the
+// unreleased labels are always replaced by the generated ones, as the
+// unreleased changes are not final.
+func mergeChangelogs(existing, generated string, unreleased ...string) string {
+	if strings.TrimSpace(existing) == "" {
+		return generated
+	}
+	old := parseMarkdownChangelog(existing)
+	gen := parseMarkdownChangelog(generated)
+
+	replaced := make(map[string]bool)
+	for _, label := range unreleased {
+		replaced[label] = true
+	}
+	known := make(map[string]bool)
+	for _, section := range old.sections {
+		if !replaced[section.label] {
+			known[section.label] = true
+		}
+	}
+
+	var sections []string
+	for _, section := range gen.sections {
+		if !known[section.label] {
+			sections = append(sections, joinLines(section.lines))
+		}
+	}
+	for _, section := range old.sections {
+		if known[section.label] {
+			sections = append(sections, joinLines(section.lines))
+		}
+	}
+
+	var links []string
+	for _, link := range gen.links {
+		if !known[linkDefRegex.FindStringSubmatch(link)[1]] {
+			links = append(links, link)
+		}
+	}
+	for _, link := range old.links {
+		if known[linkDefRegex.FindStringSubmatch(link)[1]] {
+			links = append(links, link)
+		}
+	}
+
+	merged := joinLines(old.head)
+	if len(sections) > 0 {
+		merged += "\n\n" + strings.Join(sections, "\n\n")
+	}
+	merged += "\n"
+	if len(links) > 0 {
+		merged += "\n" + strings.Join(links, "\n") + "\n"
+	}
+	return merged
+}
+
+// joinLines joins the lines without the trailing blank lines.
+func joinLines(lines []string) string {
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// insertMarker is the comment of an output file after which the new
+// versions are inserted with --insert.
+const insertMarker = "<!-- gotaglog:insert -->"
+
+// insertChangelog returns the existing changelog with the sections of the
+// generated changelog for the versions it lacks inserted after its marker
+// comment, leaving the rest of the file as is, and their links appended to
+// it. The section labeled with one of the unreleased labels right after the
+// marker, inserted by a previous run, is replaced by the generated one. It
+// reports false if the existing changelog has no marker.
+func insertChangelog(existing, generated string, unreleased ...string) (string, bool) {
+	lines := strings.Split(existing, "\n")
+	at := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == insertMarker {
+			at = i
+			break
+		}
+	}
+	if at < 0 {
+		return "", false
+	}
+
+	replaced := make(map[string]bool)
+	for _, label := range unreleased {
+		replaced[label] = true
+	}
+	before := withoutLinks(lines[:at+1], replaced)
+	after := withoutLinks(lines[at+1:], replaced)
+	for len(after) > 0 && strings.TrimSpace(after[0]) == "" {
+		after = after[1:]
+	}
+	if len(after) > 0 {
+		if match := versionHeaderRegex.FindStringSubmatch(after[0]); match != nil && replaced[match[1]] {
+			end := 1
+			for end < len(after) && !strings.HasPrefix(after[end], "## ") {
+				end++
+			}
+			after = after[end:]
+		}
+	}
+
+	known := make(map[string]bool)
+	linked := make(map[string]bool)
+	for _, line := range append(append([]string(nil), before...), after...) {
+		if match := versionHeaderRegex.FindStringSubmatch(line); match != nil {
+			known[match[1]] = true
+		}
+		if match := linkDefRegex.FindStringSubmatch(line); match != nil {
+			linked[match[1]] = true
+		}
+	}
+
+	gen := parseMarkdownChangelog(generated)
+	var sections []string
+	for _, section := range gen.sections {
+		if !known[section.label] {
+			sections = append(sections, joinLines(section.lines))
+		}
+	}
+	var links []string
+	for _, link := range gen.links {
+		if label := linkDefRegex.FindStringSubmatch(link)[1]; !known[label] && !linked[label] {
+			links = append(links, link)
+		}
+	}
+
+	inserted := joinLines(before)
+	if len(sections) > 0 {
+		inserted += "\n\n" + strings.Join(sections, "\n\n")
+	}
+	if rest := joinLines(after); rest != "" {
+		inserted += "\n\n" + rest
+	}
+	if len(links) > 0 {
+		// The links follow those ending the file, if any.
+		last := inserted[strings.LastIndex(inserted, "\n")+1:]
+		if linkDefRegex.MatchString(last) {
+			inserted += "\n"
+		} else {
+			inserted += "\n\n"
+		}
+		inserted += strings.Join(links, "\n")
+	}
+	return inserted + "\n", true
+}
+
+// withoutLinks returns the lines without the link reference definitions of
+// the labels.
+func withoutLinks(lines []string, labels map[string]bool) []string {
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if match := linkDefRegex.FindStringSubmatch(line); match != nil && labels[match[1]] {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
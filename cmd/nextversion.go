@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+	"github.com/spf13/cobra"
+)
+
+// nextVersionCmd prints the version following the latest tag, bumped
+// according to the unreleased changes.
+var nextVersionCmd = &cobra.Command{
+	Use:   "next-version",
+	Short: "Print the next version based on the unreleased changes",
+	Long: `Print the version following the latest tag, bumped according to the
+unreleased changes: a breaking change bumps the major version, a feature
+the minor version, and any other change the patch version.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		opts, err := getOptions()
+		if err != nil {
+			return err
+		}
+		version, err := changelog.NextVersion(opts)
+		if err != nil {
+			return fmt.Errorf("cannot determine next version: %w", err)
+		}
+		fmt.Println(version)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nextVersionCmd)
+}
@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/frgrisk/gotaglog/pkg/changelog"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -12,15 +14,25 @@ import (
 
 var cfgFile string
 
-const defaultUnreleasedTag = "unreleased"
-
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "gotaglog",
 	Short: "Generate a changelog from git tags",
-	Run: func(_ *cobra.Command, _ []string) {
-		getChangeLog()
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		if viper.GetBool("print-config") {
+			return printConfig()
+		}
+		if viper.GetBool("print-env") {
+			return printEnv(cmd)
+		}
+		opts, err := getOptions()
+		if err != nil {
+			return err
+		}
+		return getChangeLog(opts)
 	},
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -31,29 +43,123 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
-	log.SetFormatter(&log.TextFormatter{DisableTimestamp: true, DisableLevelTruncation: true})
 
 	cwd, err := os.Getwd()
 	if err != nil {
 		cwd = "."
 	}
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gotaglog.yaml)")
-	rootCmd.PersistentFlags().StringP("repo", "r", cwd, "path to git repository")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .gotaglog.yaml in the repository, over $HOME/.gotaglog.yaml)")
+	rootCmd.PersistentFlags().StringSliceP("repo", "r", []string{cwd}, "path to git repository, or URL of a remote repository to clone (can be repeated to combine several repositories)")
 	err = rootCmd.MarkPersistentFlagDirname("repo")
 	if err != nil {
 		panic(err)
 	}
+	rootCmd.PersistentFlags().StringP("branch", "b", "", "branch to generate the changelog for (default is HEAD)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "only log errors to stderr, leaving out warnings, progress and the config file notice")
+	rootCmd.PersistentFlags().String("log-level", log.InfoLevel.String(), "minimum level of the messages logged to stderr: debug, info, warn or error")
+	rootCmd.PersistentFlags().String("log-format", logFormatText, "format of the messages logged to stderr: text or json")
+	rootCmd.PersistentFlags().String("tag-prefix", "", "only consider tags starting with the given prefix, such as v")
+	rootCmd.PersistentFlags().String("tag-pattern", "", "regular expression matching the version tags, with a version named group capturing the semantic version and an optional component named group")
+	rootCmd.PersistentFlags().String("component", "", "only consider the tags of the given component of --tag-pattern, in a single-component changelog")
+	rootCmd.PersistentFlags().Bool("skip-prerelease", false, "ignore prerelease tags and include their changes in the next release")
+	rootCmd.PersistentFlags().String("sort-by", changelog.SortBySemver, "order of the versions: semver (semantic version precedence) or date (date of the tags, such as for calendar versioning)")
+	rootCmd.PersistentFlags().Bool("skip-merges", true, "leave out merge commits (use --skip-merges=false to parse them)")
+	rootCmd.PersistentFlags().StringSlice("scope", nil, "only include commits with the given scope (can be repeated)")
+	rootCmd.PersistentFlags().Bool("require-scope", false, "leave out the commits without scope, except breaking changes")
+	rootCmd.PersistentFlags().StringSlice("exclude-type", nil, "leave out commits with the given conventional commit type (can be repeated)")
+	rootCmd.PersistentFlags().StringSlice("include-type", nil, "only include commits with the given conventional commit type (can be repeated)")
+	rootCmd.PersistentFlags().Bool("allow-leading-emoji", false, "strip a leading emoji or gitmoji shortcode, such as :sparkles:, from commit titles before matching them")
+	rootCmd.PersistentFlags().StringSlice("path", nil, "only include commits touching the given paths (can be repeated)")
+	rootCmd.PersistentFlags().StringSlice("exclude-author", nil, "exclude commits whose author name or email matches the given pattern (can be repeated)")
 
 	rootCmd.Flags().Bool("unreleased", false, "show only unreleased changes")
+	rootCmd.Flags().Bool("no-unreleased", false, "leave out the unreleased changes, to only show released versions")
 	rootCmd.Flags().Bool("inc-major", false, "generate tag for unreleased changes by incrementing the major version")
 	rootCmd.Flags().Bool("inc-minor", false, "generate tag for unreleased changes by incrementing the minor version")
 	rootCmd.Flags().Bool("inc-patch", false, "generate tag for unreleased changes by incrementing the patch version")
-	rootCmd.Flags().StringP("tag", "t", defaultUnreleasedTag, "tag for unreleased changes")
+	rootCmd.Flags().Bool("inc-auto", false, "generate tag for unreleased changes by incrementing the version according to the changes")
+	rootCmd.Flags().Bool("date-range", false, "show the range of the dates of the unreleased commits in their header")
+	rootCmd.Flags().Int("limit", 0, "only include the given number of newest versions, including the unreleased changes (default is all the versions)")
+	rootCmd.Flags().Bool("limit-excludes-unreleased", false, "include the unreleased changes in addition to the --limit newest releases")
+	rootCmd.Flags().String("until", "", "only include versions less than or equal to the given version, without unreleased changes")
+	rootCmd.Flags().String("from-ref", "", "only include commits after the given revision, in a single section without header")
+	rootCmd.Flags().String("to-ref", "", "only include commits up to the given revision, in a single section without header (default is HEAD)")
+	rootCmd.Flags().String("order", changelog.OrderDesc, "order of the versions: desc (newest first) or asc (oldest first)")
+	rootCmd.Flags().String("capitalize", changelog.CapitalizeFirstWord, "capitalization of the commit descriptions: first-word (title-case the first word), sentence (uppercase the first letter) or never")
+	rootCmd.Flags().String("language", "", "BCP 47 tag of the language of the commit descriptions, such as tr, whose casing rules apply to their capitalization")
+	rootCmd.Flags().String("breaking-position", "first", "position of the breaking changes within each version: first or last")
+	rootCmd.Flags().String("commit-order", "", "order of the commits within each group by date: desc (newest first) or asc (oldest first) (default is the history order)")
+	rootCmd.Flags().String("since", "", "only include versions greater than or equal to the given version")
+	rootCmd.Flags().StringP("tag", "t", changelog.DefaultUnreleasedTag, "label or semantic version of the unreleased changes")
 	rootCmd.Flags().StringP("output", "o", "", "output file")
+	rootCmd.Flags().String("split-output", "", "directory to write each version to its own Markdown file, along with an index, instead of a single changelog")
+	rootCmd.Flags().Bool("tee", false, "print the changelog to stdout in addition to writing it to the output file")
+	rootCmd.Flags().Bool("merge", false, "insert the new versions into the existing output file instead of overwriting it")
+	rootCmd.Flags().Bool("insert", false, "insert the new versions after the <!-- gotaglog:insert --> comment of the output file, leaving the rest as is")
+	rootCmd.Flags().Bool("dry-run", false, "print a summary of the changelog to stderr instead of writing it")
+	rootCmd.Flags().Bool("fail-if-no-unreleased", false, "exit with an error if there are no unreleased changes, and successfully otherwise, without printing the changelog")
+	rootCmd.Flags().Bool("strict", false, "fail if any commit matches no group")
+	rootCmd.Flags().Bool("verbose", false, "list the commits left out of the changelog to stderr, with the reason, such as a skip group or no matching group")
+	rootCmd.Flags().Bool("catch-all", false, "list the commits matching no group in a group of their own instead of leaving them out")
+	rootCmd.Flags().String("catch-all-title", changelog.DefaultCatchAllTitle, "title of the group of the commits matching no group with --catch-all")
+	rootCmd.Flags().String("format", formatMarkdown, "output format: markdown, html, json or atom")
+	rootCmd.Flags().Bool("pretty", false, "indent the JSON output, and color it when printed to a terminal")
+	rootCmd.Flags().String("feed-url", "", "URL of the Atom feed, used as its self link and identifier with --format atom (default is to identify it by --repo-url)")
+	rootCmd.Flags().Bool("plain", false, "print the raw Markdown to stdout without rendering it")
+	rootCmd.Flags().String("style", "", "glamour style of the rendered changelog, such as dark, light or dracula, or the path to a JSON style file (default is detected from the terminal)")
+	rootCmd.Flags().Int("width", 0, "column at which the rendered changelog wraps, or 0 to not wrap (default is the terminal width up to 120, or 80)")
+	rootCmd.Flags().String("date-format", changelog.DefaultDateFormat, "Go time layout used to format dates in version headers")
+	rootCmd.Flags().String("date-source", changelog.DateSourceAuthor, "date of the commits and versions: author, committer, or tagger for the versions of annotated tags")
+	rootCmd.Flags().String("timezone", "", "IANA time zone of the dates, such as Europe/Paris, or UTC or local (default is the time zone of each commit)")
+	rootCmd.Flags().String("title", changelog.DefaultTitle, "text of the top-level header of the changelog, or an empty string to leave it out")
+	rootCmd.Flags().Bool("toc", false, "add a table of contents linking to each version")
+	rootCmd.Flags().Bool("include-tag-message", false, "include the message of annotated tags under their version header")
+	rootCmd.Flags().Bool("compact", false, "render each version as a single line summarizing its number of commits by type, without the lists of commits")
+	rootCmd.Flags().Bool("include-body", false, "include the body of each commit beneath its title")
+	rootCmd.Flags().Bool("breaking-notes", false, "include the text of the BREAKING CHANGE footers of each breaking change beneath its title")
+	rootCmd.Flags().Bool("fold-reverts", false, "leave out the revert commits along with the commits they revert in the same version")
+	rootCmd.Flags().Bool("dedupe", true, "list identical entries of a group only once (use --dedupe=false to keep them)")
+	rootCmd.Flags().Int("max-per-group", 0, "only list the given number of commits of each group, followed by the number of commits left out (default is all the commits)")
+	rootCmd.Flags().Bool("show-empty-groups", false, "list every group in each version, with a placeholder for the groups without commits")
+	rootCmd.Flags().Bool("group-by-scope", false, "nest the commits of each group under their scope")
+	rootCmd.Flags().Bool("no-emoji", false, "strip the leading emoji from the group titles")
+	rootCmd.Flags().Bool("show-counts", false, "show the number of commits of each version in its header")
+	rootCmd.Flags().Bool("count-all", false, "count the commits matching no group too with --show-counts")
+	rootCmd.Flags().Bool("stats", false, "show the files changed, insertions and deletions of each version below its header (slower)")
+	rootCmd.Flags().Bool("show-authors", false, "append the author and co-authors of each commit")
+	rootCmd.Flags().Bool("show-reviewers", false, "append the reviewers of the Reviewed-by trailers of each commit")
+	rootCmd.Flags().Bool("github-handles", false, "show authors and reviewers with a GitHub noreply email by their username with --show-authors or --show-reviewers")
+	rootCmd.Flags().Bool("verify-signatures", false, "mark the commits that are unsigned or whose PGP signature cannot be verified against --keyring")
+	rootCmd.Flags().String("keyring", "", "armored PGP public keyring file to verify the commit signatures against")
+	rootCmd.Flags().Bool("show-hash", false, "show the short commit hash for each entry")
+	rootCmd.Flags().String("repo-url", "", "web URL of the repository used for links (default is derived from the origin remote)")
+	rootCmd.Flags().String("commit-path", changelog.DefaultCommitPath, "path of a commit relative to the repository URL")
+	rootCmd.Flags().Bool("show-issues", false, "append the issues referenced in the message of each commit")
+	rootCmd.Flags().String("issue-url", "", "URL of an issue where {id} is replaced by the issue number (default is the issues of the repository URL)")
+	rootCmd.Flags().String("pr-url", "", "URL of a pull request where {id} is replaced by its number, to link the (#123) reference of commit titles")
+	rootCmd.Flags().Bool("all-branches", false, "include the version tags of all the branches, not only those reachable from the branch")
+	rootCmd.Flags().Bool("compare-links", false, "append links comparing each version to the previous one")
+	rootCmd.Flags().String("compare-path", changelog.DefaultComparePath, "path of a comparison between two revisions relative to the repository URL")
+	rootCmd.Flags().String("release-path", changelog.DefaultReleasePath, "path of a release relative to the repository URL")
+	rootCmd.Flags().String("template", "", "Go text/template file rendering the changelog (default is the built-in Markdown template)")
+	rootCmd.Flags().Bool("print-config", false, "print the effective configuration as YAML and exit")
+	rootCmd.Flags().Bool("print-env", false, "print the environment variables setting the flags and configuration keys and exit")
 	err = rootCmd.MarkFlagFilename("output", "md")
 	if err != nil {
 		panic(err)
 	}
+	err = rootCmd.MarkFlagFilename("keyring", "asc", "gpg")
+	if err != nil {
+		panic(err)
+	}
+	err = rootCmd.MarkFlagFilename("template", "tmpl")
+	if err != nil {
+		panic(err)
+	}
+	err = rootCmd.MarkFlagDirname("split-output")
+	if err != nil {
+		panic(err)
+	}
 	err = viper.BindPFlags(rootCmd.Flags())
 	if err != nil {
 		panic(err)
@@ -80,12 +186,116 @@ func initConfig() {
 		viper.SetConfigName(".gotaglog")
 	}
 
-	viper.SetEnvPrefix("GOTAGLOG")
+	viper.SetEnvPrefix(envPrefix)
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv() // read in environment variables that match
+	cobra.CheckErr(bindConfigEnv())
 
 	// If a config file is found, read it in.
+	var used []string
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		used = append(used, viper.ConfigFileUsed())
+	}
+	// The configuration file of the repository takes precedence over the
+	// one of the home directory.
+	if cfgFile == "" {
+		if local := findLocalConfig(); local != "" && (len(used) == 0 || !sameFile(local, used[0])) {
+			viper.SetConfigFile(local)
+			if err := viper.MergeInConfig(); err != nil {
+				cobra.CheckErr(fmt.Errorf("cannot read config file %s: %w", local, err))
+			}
+			used = append(used, local)
+		}
+	}
+	cobra.CheckErr(setupLogger())
+	if !viper.GetBool("quiet") {
+		for _, file := range used {
+			fmt.Fprintln(os.Stderr, "Using config file:", file)
+		}
+	}
+}
+
+// localConfigFile is the name of the configuration file of a repository.
+const localConfigFile = ".gotaglog.yaml"
+
+// findLocalConfig returns the path of the configuration file closest to the
+// directory of the first repository, up to the root of its worktree, or an
+// empty string if there is none or the repository is remote.
+func findLocalConfig() string {
+	repos := viper.GetStringSlice("repo")
+	if len(repos) == 0 {
+		return ""
+	}
+	dir, err := filepath.Abs(repos[0])
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+
+	var found string
+	for {
+		if found == "" {
+			path := filepath.Join(dir, localConfigFile)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				found = path
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return found
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// The directory is not in a git worktree.
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// sameFile reports whether both paths name the same existing file.
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	return err == nil && os.SameFile(infoA, infoB)
+}
+
+const (
+	// logFormatText logs human-readable messages.
+	logFormatText = "text"
+	// logFormatJSON logs one JSON object per message.
+	logFormatJSON = "json"
+)
+
+// setupLogger sets the level and format of the logger from the flags. The
+// quiet flag takes precedence over the log level.
+func setupLogger() error {
+	switch format := viper.GetString("log-format"); format {
+	case logFormatText:
+		log.SetFormatter(&log.TextFormatter{DisableTimestamp: true, DisableLevelTruncation: true})
+	case logFormatJSON:
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("invalid log format %q: must be %q or %q", format, logFormatText, logFormatJSON)
+	}
+
+	if viper.GetBool("quiet") {
+		log.SetLevel(log.ErrorLevel)
+		return nil
+	}
+	switch level := viper.GetString("log-level"); level {
+	case "debug", "info", "warn", "error":
+		lvl, err := log.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		log.SetLevel(lvl)
+	default:
+		return fmt.Errorf("invalid log level %q: must be %q, %q, %q or %q", level, "debug", "info", "warn", "error")
 	}
+	return nil
 }
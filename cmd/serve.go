@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// htmlPage wraps the HTML changelog in a standalone page.
+const htmlPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+</head>
+<body>
+%s</body>
+</html>
+`
+
+// serveCmd serves the changelog rendered as HTML.
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the changelog as HTML over HTTP",
+	Long: `Serve the changelog rendered as HTML over HTTP. The changelog is generated
+again from the repository on each request, or at most once per refresh
+interval if one is given.
+
+The persistent flags apply to this command. The other options of the
+changelog, such as limit or tag, can only be set in the configuration file
+or with GOTAGLOG_ environment variables.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		opts, err := getOptions()
+		if err != nil {
+			return err
+		}
+		// The changelog is generated on requests, not in a terminal.
+		opts.Progress = nil
+		refresh := viper.GetDuration("refresh")
+		if refresh < 0 {
+			return fmt.Errorf("invalid refresh interval %s: must not be negative", refresh)
+		}
+
+		addr := viper.GetString("addr")
+		mux := http.NewServeMux()
+		mux.Handle("/", &changelogHandler{opts: opts, refresh: refresh})
+		log.Infof("Serving the changelog on %s", addr)
+		return http.ListenAndServe(addr, mux)
+	},
+}
+
+// changelogHandler serves the changelog as an HTML page, generated at most
+// once per refresh interval.
+type changelogHandler struct {
+	opts    changelog.Options
+	refresh time.Duration
+
+	mu          sync.Mutex
+	page        []byte
+	generatedAt time.Time
+}
+
+func (h *changelogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, err := h.getPage()
+	if err != nil {
+		log.Errorf("Cannot generate changelog: %v", err)
+		http.Error(w, "cannot generate changelog", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+// getPage returns the HTML page of the changelog, generating it again if
+// the refresh interval has elapsed.
+func (h *changelogHandler) getPage() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.page != nil && time.Since(h.generatedAt) < h.refresh {
+		return h.page, nil
+	}
+	md, err := changelog.Generate(h.opts)
+	if err != nil {
+		return nil, err
+	}
+	html, err := changelog.ToHTML(md)
+	if err != nil {
+		return nil, err
+	}
+	title := h.opts.Title
+	if title == "" {
+		title = changelog.DefaultTitle
+	}
+	h.page = []byte(fmt.Sprintf(htmlPage, template.HTMLEscapeString(title), html))
+	h.generatedAt = time.Now()
+	return h.page, nil
+}
+
+func init() {
+	serveCmd.Flags().String("addr", "localhost:8080", "address to listen on")
+	serveCmd.Flags().Duration("refresh", 0, "minimum interval between two generations of the changelog, such as 5m (default is to generate it on each request)")
+	err := viper.BindPFlags(serveCmd.Flags())
+	if err != nil {
+		panic(err)
+	}
+	rootCmd.AddCommand(serveCmd)
+}
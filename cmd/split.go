@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+)
+
+// unsafeFileRegex matches the characters of a version label that are left
+// out of its file name.
+var unsafeFileRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// splitIndexFile is the name of the index of the version files.
+const splitIndexFile = "index.md"
+
+// writeSplitChangelog writes each version of the changelog to its own
+// Markdown file in dir, named after its label, along with an index linking
+// to them in the order of the changelog. The unreleased changes are written
+// to unreleased.md. Versions whose file names collide are an error.
+func writeSplitChangelog(cl *changelog.Changelog, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create output directory: %w", err)
+	}
+
+	// Each file holds the section of its version alone.
+	opts := cl.Options
+	opts.NoTitle = true
+	opts.TOC = false
+
+	var index strings.Builder
+	if !cl.Options.NoTitle {
+		fmt.Fprintf(&index, "# %s\n\n", cl.Options.Title)
+	}
+	labels := make(map[string]string, len(cl.Versions))
+	for _, version := range cl.Versions {
+		name := versionFileName(version)
+		if label, ok := labels[name]; ok {
+			return fmt.Errorf("cannot write %s and %s to the same file %s", label, version.Label, name)
+		}
+		labels[name] = version.Label
+		md, err := (&changelog.Changelog{Versions: []*changelog.Version{version}, Options: opts}).Render()
+		if err != nil {
+			return fmt.Errorf("cannot generate changelog of %s: %w", version.Label, err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(md), 0644)
+		if err != nil {
+			return fmt.Errorf("cannot write to file: %w", err)
+		}
+		fmt.Fprintf(&index, "- [%s](%s)\n", version.Label, name)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, splitIndexFile), []byte(index.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write to file: %w", err)
+	}
+	return nil
+}
+
+// versionFileName returns the name of the file of the version: its label,
+// or "unreleased" preceded by the repository and component of the
+// unreleased changes, if any, as the labels of the releases already are,
+// with the characters unsafe in file names replaced by dashes. The tag
+// name, or "version", is used instead of a label made of unsafe characters
+// only.
+func versionFileName(version *changelog.Version) string {
+	name := version.Label
+	if version.Unreleased {
+		name = "unreleased"
+		if version.Component != "" {
+			name = version.Component + "-" + name
+		}
+		if version.Repo != "" {
+			name = version.Repo + "-" + name
+		}
+	}
+	for _, candidate := range []string{name, version.Tag} {
+		if safe := safeFileName(candidate); safe != "" {
+			return safe + ".md"
+		}
+	}
+	return "version.md"
+}
+
+// safeFileName returns the name with the characters unsafe in file names
+// replaced by dashes, and without leading or trailing dashes.
+func safeFileName(name string) string {
+	return strings.Trim(unsafeFileRegex.ReplaceAllString(name, "-"), "-")
+}
@@ -0,0 +1,154 @@
+package changelog
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// atomNamespace is the XML namespace of Atom feeds.
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomFeed is an Atom feed, as described by RFC 4287.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomAuthor is the author of an Atom feed.
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+// atomLink is a link of an Atom feed or entry.
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+// atomEntry is an entry of an Atom feed.
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link,omitempty"`
+	Content atomContent `xml:"content"`
+}
+
+// atomContent is the HTML content of an Atom entry.
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// ToAtom returns the releases of the changelog as an Atom feed, with an
+// entry per release holding its changes rendered as HTML by the default
+// template. The unreleased changes are left out. The feed is updated at the
+// date of the newest release, and feedURL, if any, is its self link. The
+// feed is identified by feedURL, or else by the repository URL, one of which
+// is required, and its author is the owner of the repository.
+func ToAtom(cl *Changelog, feedURL string) (string, error) {
+	id := feedURL
+	if id == "" {
+		id = cl.RepoURL
+	}
+	if id == "" {
+		return "", errors.New("cannot identify Atom feed: set its URL or the repository URL")
+	}
+	author := cl.RepoURL
+	if author == "" {
+		author = feedURL
+	}
+	title := cl.Options.Title
+	if title == "" {
+		title = DefaultTitle
+	}
+	feed := atomFeed{
+		Xmlns:  atomNamespace,
+		ID:     id,
+		Title:  title,
+		Author: feedAuthor(author),
+	}
+	if feedURL != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "self", Href: feedURL})
+	}
+
+	tmpl, err := parseTemplate(DefaultTemplate)
+	if err != nil {
+		return "", err
+	}
+	var updated time.Time
+	for _, version := range cl.Versions {
+		if version.Unreleased {
+			continue
+		}
+		if version.Date.After(updated) {
+			updated = version.Date
+		}
+
+		// Render the changes of the version alone, without the title, its
+		// header, link or table of contents.
+		section := *version
+		section.Header = ""
+		section.CompareURL = ""
+		opts := cl.Options
+		opts.NoTitle = true
+		opts.TOC = false
+		var md strings.Builder
+		err := tmpl.Execute(&md, &Changelog{Versions: []*Version{&section}, Options: opts})
+		if err != nil {
+			return "", fmt.Errorf("cannot render template: %w", err)
+		}
+		html, err := ToHTML(md.String())
+		if err != nil {
+			return "", err
+		}
+
+		entry := atomEntry{
+			ID:      id + "#" + version.Anchor,
+			Title:   version.Label,
+			Updated: version.Date.Format(time.RFC3339),
+			Content: atomContent{Type: "html", Body: html},
+		}
+		if version.CompareURL != "" {
+			entry.Links = append(entry.Links, atomLink{Rel: "alternate", Href: version.CompareURL})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.Format(time.RFC3339)
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("cannot encode changelog as Atom: %w", err)
+	}
+	return xml.Header + string(out) + "\n", nil
+}
+
+// feedAuthor returns the author of a feed from the URL of its repository,
+// or of the feed itself: the owner, first in the path of a repository URL
+// such as https://github.com/owner/repo, or else the host.
+func feedAuthor(rawURL string) atomAuthor {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return atomAuthor{Name: rawURL}
+	}
+	site := u.Scheme + "://" + u.Host
+	owner, _, ok := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if !ok || owner == "" {
+		return atomAuthor{Name: u.Host, URI: site}
+	}
+	return atomAuthor{Name: owner, URI: site + "/" + owner}
+}
@@ -0,0 +1,78 @@
+package changelog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+)
+
+func TestToAtom(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoURL string
+		feedURL string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "repository URL",
+			repoURL: "https://github.com/acme/widget",
+			want: []string{
+				"<id>https://github.com/acme/widget</id>",
+				"<author>\n    <name>acme</name>\n    <uri>https://github.com/acme</uri>\n  </author>",
+				"<id>https://github.com/acme/widget#100---2024-01-01</id>",
+			},
+		},
+		{
+			name:    "feed URL",
+			repoURL: "https://github.com/acme/widget",
+			feedURL: "https://acme.example/widget.xml",
+			want: []string{
+				"<id>https://acme.example/widget.xml</id>",
+				"<name>acme</name>",
+				`<link rel="self" href="https://acme.example/widget.xml"></link>`,
+			},
+		},
+		{
+			name:    "feed URL only",
+			feedURL: "https://acme.example/widget.xml",
+			want: []string{
+				"<id>https://acme.example/widget.xml</id>",
+				"<author>\n    <name>acme.example</name>\n    <uri>https://acme.example</uri>\n  </author>",
+			},
+		},
+		{
+			name:    "no URL",
+			wantErr: "cannot identify Atom feed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.commit("feat: first")
+			f.tag("v1.0.0")
+			cl, err := changelog.BuildRepository(f.repo, changelog.Options{RepoURL: tt.repoURL})
+			if err != nil {
+				t.Fatalf("cannot build changelog: %v", err)
+			}
+
+			feed, err := changelog.ToAtom(cl, tt.feedURL)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cannot encode feed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(feed, want) {
+					t.Errorf("feed does not contain %q:\n%s", want, feed)
+				}
+			}
+		})
+	}
+}
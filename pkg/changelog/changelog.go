@@ -0,0 +1,1290 @@
+// Package changelog generates a Markdown changelog from the semantic
+// version tags and conventional commits of a git repository.
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Masterminds/semver"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+)
+
+const (
+	// DefaultTitle is the text of the top-level header of the changelog.
+	DefaultTitle = "Changelog"
+	// DefaultUnreleasedTag is the header label of the unreleased changes.
+	DefaultUnreleasedTag = "unreleased"
+	// DefaultCatchAllTitle is the title of the group of the commits
+	// matching no other group when it is enabled.
+	DefaultCatchAllTitle = "Other Changes"
+	// DefaultDateFormat is the time layout of the dates in version headers.
+	DefaultDateFormat = "2006-01-02"
+	// DefaultCommitPath is the path of a commit relative to the repository
+	// URL.
+	DefaultCommitPath = "/commit/{hash}"
+	// DefaultComparePath is the path of a comparison between two revisions
+	// relative to the repository URL.
+	DefaultComparePath = "/compare/{from}...{to}"
+	// DefaultReleasePath is the path of a release relative to the
+	// repository URL.
+	DefaultReleasePath = "/releases/tag/{tag}"
+)
+
+const (
+	// OrderDesc lists the newest version first.
+	OrderDesc = "desc"
+	// OrderAsc lists the oldest version first.
+	OrderAsc = "asc"
+)
+
+const (
+	// CapitalizeFirstWord title-cases the first word of the commit
+	// descriptions, as in "Add F(X) support" for "add f(x) support".
+	CapitalizeFirstWord = "first-word"
+	// CapitalizeSentence uppercases the first letter of the commit
+	// descriptions only.
+	CapitalizeSentence = "sentence"
+	// CapitalizeNever leaves the commit descriptions as written.
+	CapitalizeNever = "never"
+)
+
+// Reasons for leaving a commit out of the changelog, reported with
+// ReportSkipped.
+const (
+	// SkipReasonIgnored is for the commits matching a pattern of the
+	// IgnoreFile or SkipPatterns.
+	SkipReasonIgnored = "ignored"
+	// SkipReasonSkipGroup is for the commits matching a skipped group.
+	SkipReasonSkipGroup = "skip-group"
+	// SkipReasonExcludedType is for the commits of a type left out by
+	// ExcludeTypes or IncludeTypes.
+	SkipReasonExcludedType = "excluded-type"
+	// SkipReasonNoGroup is for the commits matching no group.
+	SkipReasonNoGroup = "no-group"
+	// SkipReasonEmptyDescription is for the commits whose title has
+	// nothing after the type and scope, such as "fix: ".
+	SkipReasonEmptyDescription = "empty-description"
+	// SkipReasonExcludedScope is for the commits outside of Scopes.
+	SkipReasonExcludedScope = "excluded-scope"
+	// SkipReasonNoScope is for the commits without scope, with
+	// RequireScope.
+	SkipReasonNoScope = "no-scope"
+	// SkipReasonDuplicate is for the commits whose entry duplicates one
+	// of the same section.
+	SkipReasonDuplicate = "duplicate"
+)
+
+const (
+	// BreakingFirst renders the breaking changes before the other groups
+	// of each version.
+	BreakingFirst = "first"
+	// BreakingLast renders the breaking changes after the other groups of
+	// each version.
+	BreakingLast = "last"
+)
+
+const (
+	// DateSourceAuthor dates the commits by their author date.
+	DateSourceAuthor = "author"
+	// DateSourceCommitter dates the commits by their committer date.
+	DateSourceCommitter = "committer"
+	// DateSourceTagger dates the versions by the tagger date of their
+	// annotated tag, or else by the author date of the tagged commit, and
+	// the commits by their author date.
+	DateSourceTagger = "tagger"
+)
+
+// Orders of the version tags.
+const (
+	// SortBySemver orders the versions by semantic version precedence.
+	SortBySemver = "semver"
+	// SortByDate orders the versions by their date according to
+	// DateSource, such as for calendar versioning.
+	SortByDate = "date"
+)
+
+// CommitGroup maps the commits whose title matches Message to the section
+// titled Group. Commits matching a group with Skip set are left out.
+type CommitGroup struct {
+	Message string `mapstructure:"message"`
+	Group   string `mapstructure:"group"`
+	Skip    bool   `mapstructure:"skip"`
+}
+
+// DefaultCommitGroups are the commit groups used when none are configured.
+var DefaultCommitGroups = []CommitGroup{
+	{Message: "^feat", Group: "✨ Features"},
+	{Message: "^fix", Group: "🐛 Fixes"},
+	{Message: "^docs", Group: "📖 Documentation"},
+	{Message: "^perf", Group: "⚡️Performance"},
+	{Message: "^refactor", Group: "✏️ Refactor"},
+	{Message: "^revert", Group: "↩️ Revert"},
+	{Message: "^style", Group: "Styling"},
+	{Message: "^test", Group: "🧪 Testing"},
+	{Message: "^build\\(deps\\)", Group: "⚙️ Dependencies"},
+	{Message: "^build\\(deps-dev\\)", Group: "⚙️ Dev Dependencies"},
+	{Message: "^build", Group: "🛠️ Build System"},
+	{Message: "^ci", Group: "🔄 Continuous Integration"},
+	{Message: "^chore\\(release\\)", Skip: true},
+	{Message: "^chore\\(ignore\\)", Skip: true},
+	{Message: "^chore", Group: "Miscellaneous Tasks"},
+}
+
+// groupSuffix is appended to each group's message regex to capture the
+// optional scope and the breaking change marker. The scope stops at the
+// first closing parenthesis, so that parentheses in the description are
+// not mistaken for part of it.
+const groupSuffix = "(?P<scope>\\([^()]*\\))?(?P<breaking>!)?:."
+
+// breakingGroup is the title of the section listing the breaking changes,
+// rendered before the other groups of each version.
+const breakingGroup = "⚠️ Breaking Changes"
+
+// DefaultBreakingKeywords mark a breaking change when a line of the commit
+// body starts with one of them, ignoring case.
+var DefaultBreakingKeywords = []string{"breaking change:", "breaking-change:"}
+
+// Options configures the generation of a changelog. The zero value of each
+// field selects its default.
+type Options struct {
+	// RepoPath is the path to the git repository, or the HTTP(S) or SSH URL
+	// of a remote repository, which is then cloned into memory.
+	RepoPath string
+	// RepoPaths are the paths to several git repositories whose changelogs
+	// are combined, interleaving their versions by date. If it holds more
+	// than one path, it takes precedence over RepoPath.
+	RepoPaths []string
+	// Groups are the commit groups, in the order in which they are
+	// rendered. Defaults to DefaultCommitGroups.
+	Groups []CommitGroup
+	// AllowLeadingEmoji strips a leading emoji, or a gitmoji shortcode such
+	// as ":sparkles:", from the commit titles before matching them against
+	// the groups, so that "✨ feat: add thing" is a feature. The emoji is
+	// left out of the entry.
+	AllowLeadingEmoji bool
+	// SkipPatterns are the regexes of the commit titles to leave out,
+	// checked before matching the groups, like the patterns of the
+	// IgnoreFile.
+	SkipPatterns []string
+	// Types maps conventional commit types, such as "feat", to group
+	// titles. The title of the group matching exactly the type replaces the
+	// title of the group; the other types are added as groups after
+	// Groups, sorted by type. An empty title leaves the commits of the type
+	// out.
+	Types map[string]string
+	// UnreleasedTag is the header label of the unreleased changes, used as
+	// provided, such as "Unreleased" or "Next". If it is a semantic
+	// version, the header is dated like a release and the increments are
+	// ignored. Defaults to DefaultUnreleasedTag.
+	UnreleasedTag string
+	// IncMajor, IncMinor and IncPatch name the unreleased changes by
+	// incrementing the latest version, unless UnreleasedTag is a semantic
+	// version. They take precedence over each other in that order.
+	IncMajor bool
+	IncMinor bool
+	IncPatch bool
+	// IncAuto names the unreleased changes by incrementing the latest
+	// version according to the changes, as NextVersion does. The other
+	// increments take precedence over it.
+	IncAuto bool
+	// DateRange shows the range of the dates of the unreleased commits in
+	// their header, such as "[unreleased] (2024-01-01 — 2024-02-15)",
+	// instead of the current date.
+	DateRange bool
+	// Branch is the branch whose changelog is generated. Only the tags
+	// reachable from it are included. Defaults to HEAD.
+	Branch string
+	// AllBranches includes the version tags of all the branches, whether
+	// or not they are reachable from Branch. Each commit is listed in the
+	// oldest version it is reachable from, so the versions of divergent
+	// branches may not follow each other.
+	AllBranches bool
+	// UnreleasedOnly restricts the changelog to the unreleased changes.
+	UnreleasedOnly bool
+	// NoUnreleased leaves the unreleased changes out of the changelog. It
+	// cannot be combined with UnreleasedOnly.
+	NoUnreleased bool
+	// Limit keeps only the given number of newest versions, including the
+	// unreleased changes unless LimitExcludesUnreleased is set. Zero keeps
+	// all the versions.
+	Limit int
+	// LimitExcludesUnreleased keeps the unreleased changes in addition to
+	// the Limit newest releases.
+	LimitExcludesUnreleased bool
+	// FromRef and ToRef restrict the changelog to the commits reachable
+	// from ToRef but not from FromRef, listed in a single section without
+	// header. They can be any revision, such as a branch, a tag or a commit
+	// hash. The tags are then ignored. ToRef defaults to the head commit,
+	// and an empty FromRef includes the whole history.
+	FromRef string
+	ToRef   string
+	// Order is the order of the version sections, either OrderDesc or
+	// OrderAsc. The unreleased changes are always at the newest end.
+	// Defaults to OrderDesc.
+	Order string
+	// CommitOrder sorts the commits of each group by date, either
+	// OrderDesc for newest first or OrderAsc for oldest first. By default,
+	// the commits are in the order of the history walk from the newest.
+	CommitOrder string
+	// Capitalize sets the capitalization of the commit descriptions,
+	// either CapitalizeFirstWord, CapitalizeSentence or CapitalizeNever.
+	// Defaults to CapitalizeFirstWord.
+	Capitalize string
+	// Language is the BCP 47 tag of the language of the commit
+	// descriptions, such as "tr", whose casing rules apply to their
+	// capitalization. Defaults to language-neutral rules.
+	Language string
+	// MaxPerGroup truncates each group to this number of commits, followed
+	// by the number of commits left out. Zero keeps all the commits.
+	MaxPerGroup int
+	// BreakingPosition places the breaking changes of each version, either
+	// BreakingFirst or BreakingLast. Defaults to BreakingFirst.
+	BreakingPosition string
+	// TagPrefix restricts the versions to the tags starting with this
+	// prefix, such as "v". The prefix is stripped before parsing the
+	// semantic version and added back in the headers.
+	TagPrefix string
+	// TagPattern is a regular expression matching the version tags, whose
+	// "version" named group captures the semantic version, such as
+	// "^(?P<component>.+)/v(?P<version>.+)$" for tags like "api/v1.2.3".
+	// The tags are grouped by component: the "component" named group if
+	// any, otherwise the text before the version. The versions of each
+	// component only list the commits since the previous version of that
+	// component, and the components are combined like RepoPaths. It cannot
+	// be combined with TagPrefix.
+	TagPattern string
+	// Component restricts the versions to the tags of this component of
+	// TagPattern, in a single-component changelog. It requires TagPattern.
+	Component string
+	// SkipPrerelease ignores the tags with a prerelease version, such as
+	// "v1.2.0-rc.1", so that their commits belong to the next release.
+	SkipPrerelease bool
+	// Since restricts the changelog to the versions greater than or equal
+	// to this semantic version.
+	Since string
+	// Until restricts the changelog to the versions less than or equal to
+	// this semantic version. The unreleased changes are left out.
+	Until string
+	// DateFormat is the time layout of the dates in version headers.
+	// Defaults to DefaultDateFormat.
+	DateFormat string
+	// Timezone is the IANA name of the time zone of the dates, such as
+	// "Europe/Paris", or "UTC" or "Local". By default, the dates are in the
+	// time zone of their commit.
+	Timezone string
+	// DateSource is the date of the commits, and of the tagged commits in
+	// version headers: DateSourceAuthor, DateSourceCommitter or
+	// DateSourceTagger. Defaults to DateSourceAuthor.
+	DateSource string
+	// SortBy is the order of the versions: SortBySemver or SortByDate,
+	// where the versions of the same date follow the semantic version
+	// order. Defaults to SortBySemver.
+	SortBy string
+	// Paths restricts the changelog to the commits touching at least one
+	// of these paths, relative to the repository root. The versions
+	// without such commits are left out.
+	Paths []string
+	// Strict fails the generation if any commit matches no group. The
+	// commits left out by the other options are not checked.
+	Strict bool
+	// ReportSkipped lists the commits left out of the changelog, along
+	// with the reason, in Changelog.Skipped.
+	ReportSkipped bool
+	// Progress, if set, is called as the tags of large repositories are
+	// processed, with the stage, such as "Processing tag", the number of
+	// items done and their total. It is called with done equal to total
+	// when the stage ends.
+	Progress func(stage string, done, total int)
+	// ShowEmptyGroups lists all the groups in each version, with a
+	// placeholder for those without commits.
+	ShowEmptyGroups bool
+	// Emoji maps conventional commit types, such as "feat", to the emoji
+	// replacing the leading emoji of the title of their group, or an empty
+	// string to remove it. The "breaking" key applies to the breaking
+	// changes. The group of a type is the one whose message is exactly the
+	// type, as with Types. NoEmoji takes precedence.
+	Emoji map[string]string
+	// CatchAll is the title of a group listing the commits matching no
+	// other group, rendered after the other groups. If it is empty, these
+	// commits are left out.
+	CatchAll string
+	// FoldReverts leaves out the revert commits along with the commits
+	// they revert, when both belong to the same version.
+	FoldReverts bool
+	// IncludeMerges parses the merge commits, those with more than one
+	// parent, like the other commits. By default, they are left out.
+	IncludeMerges bool
+	// IncludeTagMessage inserts the message of annotated tags under their
+	// version header.
+	IncludeTagMessage bool
+	// ExcludeAuthors leaves out the commits whose author name or email
+	// matches one of these patterns, ignoring case. A pattern without
+	// wildcards matches a substring; otherwise "*" matches any sequence of
+	// characters and "?" any single character.
+	ExcludeAuthors []string
+	// BreakingKeywords mark a breaking change when a line of the commit
+	// body starts with one of them, ignoring case. They are added to
+	// DefaultBreakingKeywords unless ReplaceBreakingKeywords is set. A "!"
+	// after the type or scope always marks a breaking change.
+	BreakingKeywords []string
+	// ReplaceBreakingKeywords uses BreakingKeywords instead of
+	// DefaultBreakingKeywords.
+	ReplaceBreakingKeywords bool
+	// Compact renders each version as its header followed by its Summary,
+	// without the lists of commits.
+	Compact bool
+	// IncludeBody renders the body of each commit as a quote beneath its
+	// title.
+	IncludeBody bool
+	// BreakingNotes renders the text of the breaking change footers of
+	// each breaking change, such as the migration steps of
+	// "BREAKING CHANGE: use Foo instead", as a quote beneath its title.
+	BreakingNotes bool
+	// Scopes restricts the changelog to the commits with one of these
+	// scopes, ignoring case. Versions without such commits are left out.
+	Scopes []string
+	// RequireScope leaves out the commits without scope, except breaking
+	// changes. Versions without other commits are left out.
+	RequireScope bool
+	// ExcludeTypes leaves out the commits with one of these conventional
+	// commit types, ignoring case, and IncludeTypes restricts the changelog
+	// to the commits with one of them. Versions without any remaining
+	// commits are left out.
+	ExcludeTypes []string
+	IncludeTypes []string
+	// KeepDuplicates keeps the commits whose entry is identical to a
+	// previous one of the same group, such as cherry-picks. By default,
+	// only the first one is listed.
+	KeepDuplicates bool
+	// GroupByScope nests the commits of each group under their scope,
+	// instead of showing the scope inline.
+	GroupByScope bool
+	// Title is the text of the top-level header of the changelog.
+	// Defaults to DefaultTitle.
+	Title string
+	// NoTitle leaves out the top-level header of the changelog.
+	NoTitle bool
+	// Stats adds the diff stats of the commits of each version below its
+	// header: the number of files changed and of lines inserted and
+	// deleted. Computing them requires diffing each commit.
+	Stats bool
+	// TOC adds a table of contents linking to each version.
+	TOC bool
+	// NoEmoji strips the leading emoji from the group titles when
+	// rendering them. Commits are still matched the same way.
+	NoEmoji bool
+	// ShowCounts appends the number of commits of each version to its
+	// header.
+	ShowCounts bool
+	// CountAll counts all the commits of each version, including those
+	// matching no group, instead of only the commits listed. The commits
+	// of skip groups are never counted.
+	CountAll bool
+	// ShowAuthors appends the author and the co-authors of the
+	// "Co-authored-by" trailers of each commit.
+	ShowAuthors bool
+	// ShowReviewers appends the reviewers of the "Reviewed-by" trailers of
+	// each commit.
+	ShowReviewers bool
+	// AuthorHandles maps the author emails, ignoring case, to the handles
+	// shown instead of their names, without the "@".
+	AuthorHandles map[string]string
+	// GitHubHandles shows the authors with a GitHub noreply email, such as
+	// "12345+octocat@users.noreply.github.com", by their GitHub username
+	// linked to their profile. AuthorHandles take precedence.
+	GitHubHandles bool
+	// VerifySignatures checks the PGP signature of each commit against
+	// Keyring, and marks the commits that are unsigned or whose signature
+	// cannot be verified.
+	VerifySignatures bool
+	// Keyring is the armored PGP public keyring the commit signatures are
+	// verified against. It is required by VerifySignatures.
+	Keyring string
+	// ShowHash shows the short hash of each commit.
+	ShowHash bool
+	// ShowIssues appends the issues referenced in the message of each
+	// commit, title included, such as "Closes #123".
+	ShowIssues bool
+	// RepoURL is the web URL of the repository used for links. Defaults to
+	// the URL derived from the "origin" remote, if any.
+	RepoURL string
+	// CommitPath is the path of a commit relative to RepoURL, where {hash}
+	// is replaced by the commit hash. Defaults to DefaultCommitPath.
+	CommitPath string
+	// IssueURL is the URL of an issue, where {id} is replaced by the issue
+	// number. Defaults to the issues of RepoURL.
+	IssueURL string
+	// PRURL is the URL of a pull request, where {id} is replaced by its
+	// number. If set, the last pull request reference of each commit
+	// title, such as the "(#123)" of GitHub squash merges, is moved to the
+	// end of the description as a link.
+	PRURL string
+	// CompareLinks appends links comparing each version to the previous
+	// one.
+	CompareLinks bool
+	// ComparePath is the path of a comparison relative to RepoURL, where
+	// {from} and {to} are replaced by the revisions. Defaults to
+	// DefaultComparePath.
+	ComparePath string
+	// ReleasePath is the path of a release relative to RepoURL, where {tag}
+	// is replaced by the tag name. Defaults to DefaultReleasePath.
+	ReleasePath string
+	// Template is the text/template rendering the changelog, which
+	// receives a *Changelog. Defaults to DefaultTemplate.
+	Template string
+}
+
+// compiledGroup is a CommitGroup along with its compiled regex.
+type compiledGroup struct {
+	CommitGroup
+	re *regexp.Regexp
+}
+
+// generator holds the state shared while generating a changelog.
+type generator struct {
+	opts Options
+	repo *git.Repository
+	head plumbing.Hash
+	// headName is the revision name of head used in links.
+	headName string
+	// released is the set of commits reachable from the versions built so
+	// far, with AllBranches.
+	released map[plumbing.Hash]bool
+	// rangeCache is the set of commits reachable from rangeCacheFrom,
+	// reused by reachableFrom across consecutive tags.
+	rangeCache     map[plumbing.Hash]bool
+	rangeCacheFrom plumbing.Hash
+	repoURL        string
+	groups         []compiledGroup
+	// excludeAuthors are the compiled ExcludeAuthors patterns.
+	excludeAuthors []*regexp.Regexp
+	// skipped are the commits left out, if ReportSkipped is set.
+	skipped []*SkippedCommit
+	// unmatched are the commits matching no group, collected if Strict is
+	// set.
+	unmatched []*object.Commit
+	// authorHandles are the AuthorHandles by lowercased email.
+	authorHandles map[string]string
+	// groupEmoji are the emoji of Emoji by group title.
+	groupEmoji map[string]string
+	// excludeTypes and includeTypes are the sets of the lowercased
+	// ExcludeTypes and IncludeTypes.
+	excludeTypes map[string]bool
+	includeTypes map[string]bool
+	// scopes is the set of the lowercased Scopes.
+	scopes map[string]bool
+	// keyring is the parsed Keyring, if VerifySignatures is set.
+	keyring openpgp.EntityList
+	// ignorePatterns are the compiled patterns of the IgnoreFile and of
+	// SkipPatterns.
+	ignorePatterns []*regexp.Regexp
+	// location is the location of Timezone, or nil.
+	location *time.Location
+	// tagPattern is the compiled TagPattern, or nil.
+	tagPattern *regexp.Regexp
+	// component is the component whose tags are the versions, if
+	// tagPattern is set.
+	component string
+	// breakingKeywords are the lowercased breaking change keywords.
+	breakingKeywords []string
+	// language is the language of the commit descriptions.
+	language language.Tag
+	since    *semver.Version
+	until    *semver.Version
+}
+
+// Generate returns the changelog of the repository rendered by the
+// template, as Markdown by default.
+func Generate(opts Options) (string, error) {
+	cl, err := Build(opts)
+	if err != nil {
+		return "", err
+	}
+	return cl.Render()
+}
+
+// Build returns the data model of the changelog of the repository, to be
+// rendered with Render or processed further.
+func Build(opts Options) (*Changelog, error) {
+	return buildChangelog(nil, opts)
+}
+
+// BuildRepository returns the data model of the changelog of an already
+// opened repository, such as one held in memory, like Build. RepoPath and
+// RepoPaths are ignored.
+func BuildRepository(repo *git.Repository, opts Options) (*Changelog, error) {
+	return buildChangelog(repo, opts)
+}
+
+// buildChangelog returns the data model of the changelog of the
+// repository, or of the repositories of the options if it is nil.
+func buildChangelog(repo *git.Repository, opts Options) (*Changelog, error) {
+	if opts.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit %d: must not be negative", opts.Limit)
+	}
+
+	var cl *Changelog
+	var err error
+	if repo == nil && len(opts.RepoPaths) > 1 {
+		cl, err = buildMulti(opts)
+	} else {
+		var g *generator
+		if repo != nil {
+			g, err = newGenerator(repo, opts)
+		} else {
+			g, err = openGenerator(opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		cl, err = g.buildRepository()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Limit > 0 {
+		cl.Versions = limitVersions(cl.Versions, opts.Limit, opts.LimitExcludesUnreleased, cl.Options.Order)
+	}
+	return cl, nil
+}
+
+// buildRepository returns the changelog of the repository of the
+// generator, combining the versions of each component if TagPattern is set.
+func (g *generator) buildRepository() (*Changelog, error) {
+	if g.tagPattern != nil {
+		return g.buildComponents()
+	}
+	return g.build()
+}
+
+// limitVersions returns the limit newest versions, sorted in the order,
+// along with all the unreleased changes if excludeUnreleased is set.
+func limitVersions(versions []*Version, limit int, excludeUnreleased bool, order string) []*Version {
+	newest := versions
+	if order == OrderAsc {
+		newest = make([]*Version, len(versions))
+		for i, version := range versions {
+			newest[len(versions)-1-i] = version
+		}
+	}
+
+	var kept []*Version
+	for _, version := range newest {
+		if version.Unreleased && excludeUnreleased {
+			kept = append(kept, version)
+		} else if limit > 0 {
+			kept = append(kept, version)
+			limit--
+		}
+	}
+
+	if order == OrderAsc {
+		for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+			kept[i], kept[j] = kept[j], kept[i]
+		}
+	}
+	return kept
+}
+
+// openGenerator opens the repository at opts.RepoPath, or clones it if it
+// is a URL, and returns its generator.
+func openGenerator(opts Options) (*generator, error) {
+	if opts.RepoPath == "" && len(opts.RepoPaths) == 1 {
+		opts.RepoPath = opts.RepoPaths[0]
+	}
+	if opts.RepoPath == "" {
+		return nil, errors.New("repository path is empty")
+	}
+	if isRemoteURL(opts.RepoPath) {
+		repo, err := cloneRepository(opts.RepoPath)
+		if err != nil {
+			return nil, err
+		}
+		return newGenerator(repo, opts)
+	}
+	repoPath := filepath.Clean(opts.RepoPath)
+	log.Debugf("Repository path is set to %q", repoPath)
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open repository: %w", err)
+	}
+	return newGenerator(repo, opts)
+}
+
+func newGenerator(repo *git.Repository, opts Options) (*generator, error) {
+	if opts.Groups == nil {
+		opts.Groups = DefaultCommitGroups
+	}
+	if len(opts.Types) > 0 {
+		opts.Groups = applyTypes(opts.Groups, opts.Types)
+	}
+	if opts.Title == "" {
+		opts.Title = DefaultTitle
+	}
+	if opts.UnreleasedTag == "" {
+		opts.UnreleasedTag = DefaultUnreleasedTag
+	}
+	if opts.Order == "" {
+		opts.Order = OrderDesc
+	}
+	if opts.Order != OrderDesc && opts.Order != OrderAsc {
+		return nil, fmt.Errorf("invalid order %q: must be %q or %q", opts.Order, OrderAsc, OrderDesc)
+	}
+	if opts.CommitOrder != "" && opts.CommitOrder != OrderDesc && opts.CommitOrder != OrderAsc {
+		return nil, fmt.Errorf("invalid commit order %q: must be %q or %q", opts.CommitOrder, OrderAsc, OrderDesc)
+	}
+	if opts.MaxPerGroup < 0 {
+		return nil, fmt.Errorf("invalid max per group %d: must not be negative", opts.MaxPerGroup)
+	}
+	if opts.UnreleasedOnly && opts.NoUnreleased {
+		return nil, errors.New("unreleased only cannot be combined with no unreleased")
+	}
+	if opts.Capitalize == "" {
+		opts.Capitalize = CapitalizeFirstWord
+	}
+	if opts.Capitalize != CapitalizeFirstWord && opts.Capitalize != CapitalizeSentence && opts.Capitalize != CapitalizeNever {
+		return nil, fmt.Errorf("invalid capitalization %q: must be %q, %q or %q", opts.Capitalize, CapitalizeFirstWord, CapitalizeSentence, CapitalizeNever)
+	}
+	if opts.Compact {
+		// The summaries on the version headers change their anchors.
+		opts.TOC = false
+	}
+	lang := language.Und
+	if opts.Language != "" {
+		parsed, err := language.Parse(opts.Language)
+		if err != nil {
+			return nil, fmt.Errorf("invalid language %q: %w", opts.Language, err)
+		}
+		lang = parsed
+	}
+	if opts.BreakingPosition == "" {
+		opts.BreakingPosition = BreakingFirst
+	}
+	if opts.BreakingPosition != BreakingFirst && opts.BreakingPosition != BreakingLast {
+		return nil, fmt.Errorf("invalid breaking position %q: must be %q or %q", opts.BreakingPosition, BreakingFirst, BreakingLast)
+	}
+	if opts.DateSource == "" {
+		opts.DateSource = DateSourceAuthor
+	}
+	if opts.DateSource != DateSourceAuthor && opts.DateSource != DateSourceCommitter && opts.DateSource != DateSourceTagger {
+		return nil, fmt.Errorf("invalid date source %q: must be %q, %q or %q", opts.DateSource, DateSourceAuthor, DateSourceCommitter, DateSourceTagger)
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = SortBySemver
+	}
+	if opts.SortBy != SortBySemver && opts.SortBy != SortByDate {
+		return nil, fmt.Errorf("invalid sort %q: must be %q or %q", opts.SortBy, SortBySemver, SortByDate)
+	}
+	if opts.DateFormat == "" {
+		opts.DateFormat = DefaultDateFormat
+	}
+	if opts.CommitPath == "" {
+		opts.CommitPath = DefaultCommitPath
+	}
+	if opts.ComparePath == "" {
+		opts.ComparePath = DefaultComparePath
+	}
+	if opts.ReleasePath == "" {
+		opts.ReleasePath = DefaultReleasePath
+	}
+
+	groups, err := compileGroups(opts.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	headName := "HEAD"
+	var head plumbing.Hash
+	if opts.Branch != "" {
+		headName = opts.Branch
+		head, err = resolveBranch(repo, opts.Branch)
+	} else {
+		head, err = resolveHead(repo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		opts:     opts,
+		repo:     repo,
+		head:     head,
+		headName: headName,
+		repoURL:  getRepoURL(repo, opts.RepoURL),
+		groups:   groups,
+		language: lang,
+	}
+
+	g.authorHandles = make(map[string]string, len(opts.AuthorHandles))
+	for email, handle := range opts.AuthorHandles {
+		g.authorHandles[strings.ToLower(strings.TrimSpace(email))] = strings.TrimPrefix(handle, "@")
+	}
+
+	g.groupEmoji = make(map[string]string, len(opts.Emoji))
+	typeEmoji := make(map[string]string, len(opts.Emoji))
+	for typ, emoji := range opts.Emoji {
+		if typ == "breaking" {
+			g.groupEmoji[breakingGroup] = emoji
+			continue
+		}
+		typeEmoji[typeMessage(typ)] = emoji
+	}
+	// The groups sharing a title get the emoji of the first one having
+	// one.
+	for _, group := range opts.Groups {
+		emoji, ok := typeEmoji[group.Message]
+		if _, set := g.groupEmoji[group.Group]; ok && !set && !group.Skip {
+			g.groupEmoji[group.Group] = emoji
+		}
+	}
+
+	g.excludeTypes = lowercaseSet(opts.ExcludeTypes)
+	g.includeTypes = lowercaseSet(opts.IncludeTypes)
+
+	for _, scope := range opts.Scopes {
+		if g.scopes == nil {
+			g.scopes = make(map[string]bool)
+		}
+		g.scopes[strings.ToLower(strings.TrimSpace(scope))] = true
+	}
+
+	if opts.VerifySignatures {
+		if opts.Keyring == "" {
+			return nil, errors.New("verifying signatures requires a keyring")
+		}
+		g.keyring, err = readKeyring(opts.Keyring)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g.ignorePatterns, err = loadIgnorePatterns(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range opts.SkipPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid skip pattern %q: %w", pattern, err)
+		}
+		g.ignorePatterns = append(g.ignorePatterns, re)
+	}
+
+	for _, pattern := range opts.ExcludeAuthors {
+		g.excludeAuthors = append(g.excludeAuthors, compileAuthorPattern(pattern))
+	}
+
+	if !opts.ReplaceBreakingKeywords {
+		g.breakingKeywords = append(g.breakingKeywords, DefaultBreakingKeywords...)
+	}
+	for _, keyword := range opts.BreakingKeywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" {
+			g.breakingKeywords = append(g.breakingKeywords, keyword)
+		}
+	}
+
+	if opts.Timezone != "" {
+		g.location, err = loadLocation(opts.Timezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.TagPattern != "" {
+		if opts.TagPrefix != "" {
+			return nil, errors.New("tag pattern cannot be combined with tag prefix")
+		}
+		g.tagPattern, err = compileTagPattern(opts.TagPattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Component != "" {
+		if g.tagPattern == nil {
+			return nil, errors.New("component requires a tag pattern")
+		}
+		g.component = opts.Component
+	}
+
+	if opts.Since != "" {
+		g.since, err = semver.NewVersion(opts.Since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since version %q: %w", opts.Since, err)
+		}
+	}
+
+	if opts.Until != "" {
+		if opts.UnreleasedOnly {
+			return nil, errors.New("until cannot be combined with unreleased only")
+		}
+		g.until, err = semver.NewVersion(opts.Until)
+		if err != nil {
+			return nil, fmt.Errorf("invalid until version %q: %w", opts.Until, err)
+		}
+	}
+
+	return g, nil
+}
+
+// lowercaseSet returns the set of the lowercased values, or nil if there
+// are none.
+func lowercaseSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.ToLower(strings.TrimSpace(value))] = true
+	}
+	return set
+}
+
+// applyTypes returns a copy of the groups with the group titles of the
+// types applied, as described by Options.Types.
+func applyTypes(groups []CommitGroup, types map[string]string) []CommitGroup {
+	groups = append([]CommitGroup(nil), groups...)
+	applied := make(map[string]bool, len(types))
+	for i, group := range groups {
+		for typ, title := range types {
+			if group.Message == typeMessage(typ) {
+				groups[i] = CommitGroup{Message: group.Message, Group: title, Skip: title == ""}
+				applied[typ] = true
+			}
+		}
+	}
+
+	added := make([]string, 0, len(types))
+	for typ := range types {
+		if !applied[typ] {
+			added = append(added, typ)
+		}
+	}
+	sort.Strings(added)
+	for _, typ := range added {
+		groups = append(groups, CommitGroup{Message: typeMessage(typ), Group: types[typ], Skip: types[typ] == ""})
+	}
+	return groups
+}
+
+// typeMessage returns the message regex of the commits of the type.
+func typeMessage(typ string) string {
+	return "^" + regexp.QuoteMeta(strings.TrimSpace(typ))
+}
+
+// compileGroups validates the commit groups and compiles their regexes.
+func compileGroups(groups []CommitGroup) ([]compiledGroup, error) {
+	compiled := make([]compiledGroup, 0, len(groups))
+	for i, group := range groups {
+		if group.Group == "" && !group.Skip {
+			return nil, fmt.Errorf("group %d (%q) has no title", i+1, group.Message)
+		}
+		re, err := regexp.Compile(group.Message + groupSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("group %d has an invalid message regex %q: %w", i+1, group.Message, err)
+		}
+		compiled = append(compiled, compiledGroup{CommitGroup: group, re: re})
+	}
+	return compiled, nil
+}
+
+// build returns the data model of the changelog.
+func (g *generator) build() (*Changelog, error) {
+	if g.opts.FromRef != "" || g.opts.ToRef != "" {
+		return g.buildRange()
+	}
+
+	semverTags, tagMap, err := g.getVersionTags()
+	if err != nil {
+		return nil, err
+	}
+
+	if g.until != nil {
+		var capped semver.Collection
+		for _, ver := range semverTags {
+			if !ver.GreaterThan(g.until) {
+				capped = append(capped, ver)
+			}
+		}
+		semverTags = capped
+	}
+
+	cl := &Changelog{Options: g.opts, RepoURL: g.repoURL}
+	withLinks := g.opts.CompareLinks && g.repoURL != ""
+
+	var prevTag *plumbing.Reference
+	defer g.progress("Processing tag", len(semverTags), len(semverTags))
+	for i, ver := range semverTags {
+		g.progress("Processing tag", i, len(semverTags))
+		if err := g.release(prevTag); err != nil {
+			return nil, err
+		}
+		tag := tagMap[ver.String()]
+		if g.opts.UnreleasedOnly || (g.since != nil && ver.LessThan(g.since)) {
+			prevTag = tag
+			continue
+		}
+		commit, err := g.getTagCommit(tag)
+		if err != nil {
+			return nil, err
+		}
+		version, err := g.getTagEntryDetails(prevTag, tag)
+		if err != nil {
+			return nil, err
+		}
+		if (len(g.scopes) > 0 || g.opts.RequireScope || g.excludeTypes != nil || g.includeTypes != nil || len(g.opts.Paths) > 0) && !version.hasChanges() {
+			log.Debugf("Skipping version %q without commits in the scopes, types and paths", g.versionLabel(ver))
+			prevTag = tag
+			continue
+		}
+		version.Label = g.versionLabel(ver)
+		version.Tag = tag.Name().Short()
+		version.Date = g.tagDate(tag, commit)
+		g.setHeader(version)
+		if g.opts.IncludeTagMessage {
+			version.Message = g.getTagMessage(tag)
+		}
+		if withLinks {
+			var from string
+			if prevTag != nil {
+				from = prevTag.Name().Short()
+			}
+			version.CompareURL = g.getCompareURL(from, version.Tag)
+		}
+		cl.Versions = g.addNewest(cl.Versions, version)
+		prevTag = tag
+	}
+
+	// Without any version tag, all the commits are unreleased, following
+	// version 0.0.0.
+	if g.until == nil && !g.opts.NoUnreleased && !g.head.IsZero() {
+		if err := g.release(prevTag); err != nil {
+			return nil, err
+		}
+		latest := semver.MustParse("0.0.0")
+		var tag *plumbing.Reference
+		if len(semverTags) > 0 {
+			latest = semverTags[len(semverTags)-1]
+			tag = tagMap[latest.String()]
+		}
+		version, err := g.getTagEntryDetails(tag, nil)
+		if err != nil {
+			return nil, err
+		}
+		g.setUnreleasedHeader(version, latest)
+		// The unreleased header is only shown along with unreleased
+		// changes, whether or not the releases are shown too.
+		if version.hasChanges() {
+			version.Unreleased = true
+			if withLinks && tag != nil {
+				version.CompareURL = g.getCompareURL(tag.Name().Short(), g.headName)
+			}
+			cl.Versions = g.addNewest(cl.Versions, version)
+		}
+	}
+
+	if err := g.checkUnmatched(); err != nil {
+		return nil, err
+	}
+	cl.Skipped = g.skipped
+	return cl, nil
+}
+
+// buildRange returns the changelog of the commits between FromRef and
+// ToRef, as a single version without header.
+func (g *generator) buildRange() (*Changelog, error) {
+	var from, to *plumbing.Reference
+	var err error
+	if g.opts.FromRef != "" {
+		from, err = g.resolveRef(g.opts.FromRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.ToRef != "" {
+		to, err = g.resolveRef(g.opts.ToRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	version, err := g.getTagEntryDetails(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkUnmatched(); err != nil {
+		return nil, err
+	}
+	cl := &Changelog{Options: g.opts, RepoURL: g.repoURL, Versions: []*Version{version}, Skipped: g.skipped}
+	// The version has no header to link to.
+	cl.Options.TOC = false
+	return cl, nil
+}
+
+// checkUnmatched returns an error listing the commits matching no group, if
+// any.
+func (g *generator) checkUnmatched() error {
+	if len(g.unmatched) == 0 {
+		return nil
+	}
+	var list strings.Builder
+	for _, c := range g.unmatched {
+		fmt.Fprintf(&list, "\n  %s %s", c.Hash.String()[:7], strings.Split(c.Message, "\n")[0])
+	}
+	return fmt.Errorf("%d commits match no group:%s", len(g.unmatched), list.String())
+}
+
+// getVersionTags returns the semantic versions of the tags reachable from
+// the head commit, sorted from oldest to newest, along with their tags by
+// version.
+func (g *generator) getVersionTags() (semver.Collection, map[string]*plumbing.Reference, error) {
+	tags, err := g.repo.Tags()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot fetch tags: %w", err)
+	}
+
+	// The tags are listed first to report the progress of the filtering.
+	var refs []*plumbing.Reference
+	err = tags.ForEach(func(tag *plumbing.Reference) error {
+		refs = append(refs, tag)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot iterate tags: %w", err)
+	}
+
+	var semverTags semver.Collection
+	tagMap := make(map[string]*plumbing.Reference)
+	dates := make(map[string]time.Time)
+
+	defer g.progress("Filtering tags", len(refs), len(refs))
+	for i, tag := range refs {
+		g.progress("Filtering tags", i, len(refs))
+		name := tag.Name().Short()
+		ver, component, ok := g.tagVersion(name)
+		if !ok || component != g.component {
+			continue
+		}
+
+		// Only tags reachable from the head commit are part of its history,
+		// unless the tags of all the branches are included.
+		commit, err := g.getTagCommit(tag)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot iterate tags: %w", err)
+		}
+		ancestor, err := g.isAncestorCommit(commit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot iterate tags: %w", err)
+		}
+		if !ancestor && !g.opts.AllBranches {
+			log.Debugf("Skipping tag %q not reachable from %s", name, g.headName)
+			continue
+		}
+
+		semverTags = append(semverTags, ver)
+		tagMap[ver.String()] = tag
+		dates[ver.String()] = g.tagDate(tag, commit)
+	}
+
+	sort.Sort(semverTags)
+	if g.opts.SortBy == SortByDate {
+		sort.SliceStable(semverTags, func(i, j int) bool {
+			return dates[semverTags[i].String()].Before(dates[semverTags[j].String()])
+		})
+	}
+	return semverTags, tagMap, nil
+}
+
+// progress reports that done of the total items of the stage are
+// processed, if a progress function is set.
+func (g *generator) progress(stage string, done, total int) {
+	if g.opts.Progress != nil {
+		g.opts.Progress(stage, done, total)
+	}
+}
+
+// addNewest adds the versions at the end of the list holding the newest
+// version, according to the order.
+func (g *generator) addNewest(versions []*Version, newest ...*Version) []*Version {
+	if g.opts.Order == OrderAsc {
+		return append(versions, newest...)
+	}
+	return append(newest, versions...)
+}
+
+// headerAnchor returns the anchor of a header as generated by GitHub:
+// lowercased, with spaces replaced by hyphens and punctuation removed.
+func headerAnchor(header string) string {
+	var anchor strings.Builder
+	for _, r := range strings.ToLower(header) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			anchor.WriteRune(r)
+		case r == ' ':
+			anchor.WriteRune('-')
+		}
+	}
+	return anchor.String()
+}
+
+// tagVersion returns the version and component of the tag, and whether it
+// is a version tag at all.
+func (g *generator) tagVersion(name string) (*semver.Version, string, bool) {
+	version, component := strings.TrimPrefix(name, g.opts.TagPrefix), ""
+	if g.tagPattern != nil {
+		match := g.tagPattern.FindStringSubmatchIndex(name)
+		if match == nil {
+			return nil, "", false
+		}
+		i := g.tagPattern.SubexpIndex("version")
+		version = name[match[2*i]:match[2*i+1]]
+		if j := g.tagPattern.SubexpIndex("component"); j >= 0 && match[2*j] >= 0 {
+			component = name[match[2*j]:match[2*j+1]]
+		} else {
+			component = strings.TrimRight(name[:match[2*i]], "/-_@")
+		}
+	} else if !strings.HasPrefix(name, g.opts.TagPrefix) {
+		return nil, "", false
+	}
+
+	ver, err := semver.NewVersion(version)
+	if err != nil || (g.opts.SkipPrerelease && ver.Prerelease() != "") {
+		return nil, "", false
+	}
+	return ver, component, true
+}
+
+// versionLabel returns the label of the version in headers and links, with
+// the tag prefix added back.
+func (g *generator) versionLabel(ver *semver.Version) string {
+	return g.opts.TagPrefix + ver.String()
+}
+
+// setUnreleasedHeader sets the label, date and header of the unreleased
+// changes of the version following the latest version.
+func (g *generator) setUnreleasedHeader(version *Version, latest *semver.Version) {
+	// The label is used as provided, except for the brackets added back in
+	// the header.
+	unreleasedTag := strings.TrimSuffix(strings.TrimPrefix(g.opts.UnreleasedTag, "["), "]")
+
+	var unreleasedVer *semver.Version
+	if v, err := semver.NewVersion(unreleasedTag); err == nil {
+		unreleasedVer = v
+		if g.opts.IncMajor || g.opts.IncMinor || g.opts.IncPatch || g.opts.IncAuto {
+			log.Warnf("Unreleased tag %q takes precedence over the version increment.", unreleasedTag)
+		}
+		if unreleasedVer.LessThan(latest) {
+			log.Warnf("Unreleased tag %q is lower than existing tag %q in the repository.", unreleasedVer, latest)
+		}
+		if unreleasedVer.Equal(latest) {
+			log.Warnf("Unreleased tag %q already exists in the repository.", unreleasedVer)
+		}
+	} else if g.opts.IncMajor {
+		v := latest.IncMajor()
+		unreleasedVer = &v
+	} else if g.opts.IncMinor {
+		v := latest.IncMinor()
+		unreleasedVer = &v
+	} else if g.opts.IncPatch {
+		v := latest.IncPatch()
+		unreleasedVer = &v
+	} else if g.opts.IncAuto {
+		v := bumpVersion(latest, version)
+		unreleasedVer = &v
+	}
+
+	version.Label = unreleasedTag
+	if unreleasedVer != nil {
+		version.Label = g.versionLabel(unreleasedVer)
+		version.Date = g.localTime(time.Now())
+	}
+	if g.opts.DateRange {
+		if first, last := version.commitDateRange(); !first.IsZero() {
+			version.DateRange = first.Format(g.opts.DateFormat)
+			if to := last.Format(g.opts.DateFormat); to != version.DateRange {
+				version.DateRange += " — " + to
+			}
+		}
+	}
+	g.setHeader(version)
+}
+
+// loadLocation returns the location of the time zone name, where "UTC"
+// and "Local" are case insensitive.
+func loadLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return location, nil
+}
+
+// commitDate returns the date of the commit according to DateSource, in
+// the Timezone.
+func (g *generator) commitDate(c *object.Commit) time.Time {
+	if g.opts.DateSource == DateSourceCommitter {
+		return g.localTime(c.Committer.When)
+	}
+	return g.localTime(c.Author.When)
+}
+
+// tagDate returns the date of the version of the tag pointing to the
+// commit: the tagger date of an annotated tag if DateSource is
+// DateSourceTagger, or else the date of the commit.
+func (g *generator) tagDate(tag *plumbing.Reference, commit *object.Commit) time.Time {
+	if g.opts.DateSource == DateSourceTagger {
+		if obj, err := g.repo.TagObject(tag.Hash()); err == nil {
+			return g.localTime(obj.Tagger.When)
+		}
+		// Lightweight tags have no tagger.
+	}
+	return g.commitDate(commit)
+}
+
+// localTime returns the time in the Timezone, if set.
+func (g *generator) localTime(t time.Time) time.Time {
+	if g.location == nil {
+		return t
+	}
+	return t.In(g.location)
+}
+
+// setHeader sets the header of the version, with its date range if any or
+// else its date unless it is zero, its number of commits if ShowCounts is
+// set, and its anchor.
+func (g *generator) setHeader(version *Version) {
+	header := fmt.Sprintf("[%s]", version.Label)
+	if version.DateRange != "" {
+		header += " (" + version.DateRange + ")"
+	} else if !version.Date.IsZero() {
+		header += " - " + version.Date.Format(g.opts.DateFormat)
+	}
+	if g.opts.ShowCounts {
+		unit := "commits"
+		if version.CommitCount == 1 {
+			unit = "commit"
+		}
+		header += fmt.Sprintf(" (%d %s)", version.CommitCount, unit)
+	}
+	version.Header = header
+	version.Anchor = headerAnchor(header)
+}
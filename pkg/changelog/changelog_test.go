@@ -0,0 +1,667 @@
+package changelog_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+)
+
+func TestBuildRepository(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(f *fixture)
+		opts  changelog.Options
+		want  string
+	}{
+		{
+			name: "unreleased changes only",
+			setup: func(f *fixture) {
+				f.commit("feat: add the thing")
+				f.commit("fix(api): handle nil")
+			},
+			want: `# Changelog
+
+## [unreleased]
+
+### ✨ Features
+
+- Add the thing
+
+### 🐛 Fixes
+
+- (**api**) Handle nil
+`,
+		},
+		{
+			name: "versions newest first",
+			setup: func(f *fixture) {
+				f.commit("feat: first")
+				f.tag("v1.0.0")
+				f.commit("fix: second")
+				f.commit("chore(release): 1.0.1")
+				f.tag("v1.0.1")
+				f.commit("docs: third")
+			},
+			want: `# Changelog
+
+## [unreleased]
+
+### 📖 Documentation
+
+- Third
+
+## [1.0.1] - 2024-01-03
+
+### 🐛 Fixes
+
+- Second
+
+## [1.0.0] - 2024-01-01
+
+### ✨ Features
+
+- First
+`,
+		},
+		{
+			name: "next version without unreleased changes",
+			setup: func(f *fixture) {
+				f.commit("feat: first")
+				f.tag("v1.2.0")
+			},
+			opts: changelog.Options{IncMinor: true},
+			want: `# Changelog
+
+## [1.2.0] - 2024-01-01
+
+### ✨ Features
+
+- First
+`,
+		},
+		{
+			name: "next version only without unreleased changes",
+			setup: func(f *fixture) {
+				f.commit("feat: first")
+				f.tag("v1.2.0")
+			},
+			opts: changelog.Options{IncMinor: true, UnreleasedOnly: true},
+			want: `# Changelog
+`,
+		},
+		{
+			name: "breaking changes first",
+			setup: func(f *fixture) {
+				f.commit("feat: keep")
+				f.commit("feat(api)!: drop the endpoint")
+				f.commit("fix: rename the flag\n\nBREAKING CHANGE: use --bar instead.")
+			},
+			want: `# Changelog
+
+## [unreleased]
+
+### ⚠️ Breaking Changes
+
+- **fix**: Rename the flag
+- **feat**: (**api**) Drop the endpoint
+
+### ✨ Features
+
+- Keep
+`,
+		},
+		{
+			name: "breaking scope with a slash",
+			setup: func(f *fixture) {
+				f.commit("feat(api/v2)!: change the routes")
+			},
+			want: `# Changelog
+
+## [unreleased]
+
+### ⚠️ Breaking Changes
+
+- **feat**: (**api/v2**) Change the routes
+`,
+		},
+		{
+			name: "scope with a dot",
+			setup: func(f *fixture) {
+				f.commit("feat(pkg.sub): add the helper")
+			},
+			want: `# Changelog
+
+## [unreleased]
+
+### ✨ Features
+
+- (**pkg.sub**) Add the helper
+`,
+		},
+		{
+			name: "parentheses in the description",
+			setup: func(f *fixture) {
+				f.commit("fix(cli): handle (nil) values")
+				f.commit("fix: parse the flags (again)")
+			},
+			want: `# Changelog
+
+## [unreleased]
+
+### 🐛 Fixes
+
+- Parse the flags (again)
+- (**cli**) Handle (nil) values
+`,
+		},
+		{
+			name: "issues referenced in the title",
+			setup: func(f *fixture) {
+				f.commit("fix: handle nil map (closes #42)")
+				f.commit("feat: add the export (#7)\n\nRefs #7, #8")
+			},
+			opts: changelog.Options{ShowIssues: true, RepoURL: "https://github.com/acme/widget", PRURL: "https://github.com/acme/widget/pull/{id}"},
+			want: `# Changelog
+
+## [unreleased]
+
+### ✨ Features
+
+- Add the export ([#7](https://github.com/acme/widget/pull/7)) ([#8](https://github.com/acme/widget/issues/8))
+
+### 🐛 Fixes
+
+- Handle nil map (closes #42) ([#42](https://github.com/acme/widget/issues/42))
+`,
+		},
+		{
+			name: "breaking notes",
+			setup: func(f *fixture) {
+				f.commit("fix: rename the flag\n\nBREAKING CHANGE: use --bar\ninstead.\nRefs: #12")
+			},
+			opts: changelog.Options{BreakingNotes: true},
+			want: `# Changelog
+
+## [unreleased]
+
+### ⚠️ Breaking Changes
+
+- **fix**: Rename the flag
+  > use --bar instead.
+`,
+		},
+		{
+			name: "body without wrapped breaking footer",
+			setup: func(f *fixture) {
+				f.commit("fix: rename the flag\n\nThe old name was confusing.\n\nBREAKING CHANGE: use --bar\ninstead.\nRefs: #12")
+			},
+			opts: changelog.Options{IncludeBody: true},
+			want: `# Changelog
+
+## [unreleased]
+
+### ⚠️ Breaking Changes
+
+- **fix**: Rename the flag
+  > The old name was confusing.
+  >
+  > Refs: #12
+`,
+		},
+		{
+			name: "tags of other branches left out",
+			setup: func(f *fixture) {
+				f.commit("feat: base")
+				f.tag("v1.0.0")
+				f.checkout("maintenance", "HEAD")
+				f.commit("fix: backport")
+				f.tag("v1.0.1")
+				f.checkout("next", "v1.0.0")
+				f.commit("feat: next")
+			},
+			want: `# Changelog
+
+## [unreleased]
+
+### ✨ Features
+
+- Next
+
+## [1.0.0] - 2024-01-01
+
+### ✨ Features
+
+- Base
+`,
+		},
+		{
+			name:  "diamond history",
+			setup: (*fixture).diamond,
+			// v9.0.0 is not reachable from HEAD, and the fix of the right
+			// side is listed again in 2.0.0, which is the first version after
+			// 1.1.0 to reach it.
+			want: `# Changelog
+
+## [unreleased]
+
+### 📖 Documentation
+
+- After
+
+## [2.0.0] - 2024-01-05
+
+### 🐛 Fixes
+
+- Right
+
+## [1.1.0] - 2024-01-02
+
+### ✨ Features
+
+- Left
+
+## [1.0.1] - 2024-01-03
+
+### 🐛 Fixes
+
+- Right
+
+## [1.0.0] - 2024-01-01
+
+### ✨ Features
+
+- Base
+`,
+		},
+		{
+			name: "tags of all branches",
+			setup: func(f *fixture) {
+				f.commit("feat: base")
+				f.tag("v1.0.0")
+				f.checkout("maintenance", "HEAD")
+				f.commit("fix: backport")
+				f.tag("v1.0.1")
+				f.checkout("next", "v1.0.0")
+				f.commit("feat: next")
+				f.tag("v1.1.0")
+			},
+			opts: changelog.Options{AllBranches: true},
+			want: `# Changelog
+
+## [1.1.0] - 2024-01-03
+
+### ✨ Features
+
+- Next
+
+## [1.0.1] - 2024-01-02
+
+### 🐛 Fixes
+
+- Backport
+
+## [1.0.0] - 2024-01-01
+
+### ✨ Features
+
+- Base
+`,
+		},
+		{
+			name: "components of all branches",
+			setup: func(f *fixture) {
+				f.commit("feat(api): serve")
+				f.tag("api/v1.0.0")
+				f.checkout("cli", "HEAD")
+				f.commit("feat(cli): run")
+				f.tag("cli/v1.0.0")
+				f.checkout("next", "api/v1.0.0")
+				f.commit("fix(api): handle nil")
+				f.tag("api/v1.0.1")
+			},
+			opts: changelog.Options{TagPattern: `^(?P<component>[a-z]+)/v(?P<version>.+)$`, AllBranches: true, NoUnreleased: true},
+			want: `# Changelog
+
+## [api 1.0.1] - 2024-01-03
+
+### 🐛 Fixes
+
+- (**api**) Handle nil
+
+## [cli 1.0.0] - 2024-01-02
+
+### ✨ Features
+
+- (**cli**) Run
+- (**api**) Serve
+
+## [api 1.0.0] - 2024-01-01
+
+### ✨ Features
+
+- (**api**) Serve
+`,
+		},
+		{
+			name: "sort by date",
+			setup: func(f *fixture) {
+				f.commit("feat: first")
+				f.tag("2024.1.0")
+				f.commit("fix: second")
+				f.tag("2024.2.0")
+				f.commit("fix: third")
+				f.tag("2024.2.0-hotfix")
+			},
+			opts: changelog.Options{SortBy: changelog.SortByDate},
+			want: `# Changelog
+
+## [2024.2.0-hotfix] - 2024-01-03
+
+### 🐛 Fixes
+
+- Third
+
+## [2024.2.0] - 2024-01-02
+
+### 🐛 Fixes
+
+- Second
+
+## [2024.1.0] - 2024-01-01
+
+### ✨ Features
+
+- First
+`,
+		},
+		{
+			name: "tagger date",
+			setup: func(f *fixture) {
+				f.commit("feat: first")
+				f.annotatedTag("v1.0.0", "First release")
+			},
+			opts: changelog.Options{DateSource: changelog.DateSourceTagger, IncludeTagMessage: true},
+			want: `# Changelog
+
+## [1.0.0] - 2024-01-02
+
+First release
+
+### ✨ Features
+
+- First
+`,
+		},
+		{
+			name: "compact",
+			setup: func(f *fixture) {
+				f.commit("feat: one")
+				f.commit("feat: two")
+				f.commit("fix!: three")
+				f.commit("docs: four")
+			},
+			opts: changelog.Options{Compact: true},
+			want: `# Changelog
+
+## [unreleased] — 2 feat, 1 docs, **1 breaking**
+`,
+		},
+		{
+			name: "versions without commits in the paths left out",
+			setup: func(f *fixture) {
+				f.commitFile("api/server.go", "feat: serve")
+				f.tag("v1.0.0")
+				f.commitFile("docs/guide.md", "docs: write the guide")
+				f.tag("v1.0.1")
+				f.commitFile("api/client.go", "feat: add the client")
+				f.commitFile("README.md", "docs: update the readme")
+				f.tag("v1.1.0")
+				f.commitFile("docs/faq.md", "docs: add the FAQ")
+			},
+			opts: changelog.Options{Paths: []string{"api"}},
+			want: `# Changelog
+
+## [1.1.0] - 2024-01-04
+
+### ✨ Features
+
+- Add the client
+
+## [1.0.0] - 2024-01-01
+
+### ✨ Features
+
+- Serve
+`,
+		},
+		{
+			name: "shared group titles",
+			setup: func(f *fixture) {
+				f.commit("feat: one")
+				f.commit("fix: two")
+				f.commit("perf: three")
+			},
+			opts: changelog.Options{Types: map[string]string{"feat": "Improvements", "perf": "Improvements"}},
+			want: `# Changelog
+
+## [unreleased]
+
+### Improvements
+
+- Three
+- One
+
+### 🐛 Fixes
+
+- Two
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			tt.setup(f)
+			if got := f.render(tt.opts); got != tt.want {
+				t.Errorf("changelog mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkBuildRepository(b *testing.B) {
+	f := newLargeFixture(b, 300)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := changelog.BuildRepository(f.repo, changelog.Options{}); err != nil {
+			b.Fatalf("cannot build changelog: %v", err)
+		}
+	}
+}
+
+func TestBuildRepositoryEmpty(t *testing.T) {
+	f := newFixture(t)
+	cl, err := changelog.BuildRepository(f.repo, changelog.Options{})
+	if err != nil {
+		t.Fatalf("cannot build changelog: %v", err)
+	}
+	if len(cl.Versions) != 0 {
+		t.Errorf("got %d versions, want none", len(cl.Versions))
+	}
+}
+
+func TestBuildBare(t *testing.T) {
+	tests := []struct {
+		name     string
+		branches []string
+		want     string
+		wantErr  string
+	}{
+		{name: "main", branches: []string{"dev", "main", "master"}, want: "Main"},
+		{name: "master", branches: []string{"dev", "master"}, want: "Master"},
+		{name: "only branch", branches: []string{"trunk"}, want: "Trunk"},
+		{name: "no default branch", branches: []string{"dev", "trunk"}, wantErr: "cannot resolve HEAD or a default branch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newBareRepository(t, tt.branches...)
+			cl, err := changelog.Build(changelog.Options{RepoPath: dir})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cannot build changelog: %v", err)
+			}
+			md, err := cl.Render()
+			if err != nil {
+				t.Fatalf("cannot render changelog: %v", err)
+			}
+			want := "# Changelog\n\n## [unreleased]\n\n### ✨ Features\n\n- " + tt.want + "\n"
+			if md != want {
+				t.Errorf("changelog mismatch\ngot:\n%s\nwant:\n%s", md, want)
+			}
+		})
+	}
+}
+
+func TestBuildRepositoriesComponents(t *testing.T) {
+	root := t.TempDir()
+	var paths []string
+	for _, name := range []string{"alpha", "beta"} {
+		dir := filepath.Join(root, name)
+		f := newFixtureAt(t, dir)
+		f.commit("feat(api): serve " + name)
+		f.tag("api/v1.0.0")
+		f.commit("feat(cli): run " + name)
+		f.tag("cli/v1.0.0")
+		paths = append(paths, dir)
+	}
+
+	cl, err := changelog.Build(changelog.Options{
+		RepoPaths:    paths,
+		TagPattern:   `^(?P<component>[a-z]+)/v(?P<version>.+)$`,
+		NoUnreleased: true,
+	})
+	if err != nil {
+		t.Fatalf("cannot build changelog: %v", err)
+	}
+	var got []string
+	for _, version := range cl.Versions {
+		got = append(got, version.Repo+"/"+version.Component+": "+version.Label)
+	}
+	want := []string{
+		"alpha/cli: alpha cli 1.0.0",
+		"beta/cli: beta cli 1.0.0",
+		"alpha/api: alpha api 1.0.0",
+		"beta/api: beta api 1.0.0",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got versions %q, want %q", got, want)
+	}
+}
+
+func TestBuildLocalPathWithColon(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "releases:2024")
+	f := newFixtureAt(t, dir)
+	f.commit("feat: first")
+
+	cl, err := changelog.Build(changelog.Options{RepoPath: dir})
+	if err != nil {
+		t.Fatalf("cannot build changelog: %v", err)
+	}
+	if len(cl.Versions) != 1 {
+		t.Errorf("got %d versions, want 1", len(cl.Versions))
+	}
+}
+
+func TestBuildRepositorySkipped(t *testing.T) {
+	f := newFixture(t)
+	f.commit("feat: kept")
+	f.commit("random commit")
+	f.commit("chore(release): 1.0.0")
+	f.commit("feat: kept")
+
+	cl, err := changelog.BuildRepository(f.repo, changelog.Options{ReportSkipped: true})
+	if err != nil {
+		t.Fatalf("cannot build changelog: %v", err)
+	}
+	var got []string
+	for _, c := range cl.Skipped {
+		got = append(got, c.Reason)
+	}
+	want := []string{
+		changelog.SkipReasonSkipGroup + ` ^chore\(release\)`,
+		changelog.SkipReasonNoGroup,
+		changelog.SkipReasonDuplicate,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got skip reasons %q, want %q", got, want)
+	}
+}
+
+func TestBuildRepositoryEmptyDescription(t *testing.T) {
+	tests := []struct {
+		message string
+		reason  string
+	}{
+		{"fix: ", changelog.SkipReasonEmptyDescription},
+		{"feat(api)!:  ", changelog.SkipReasonEmptyDescription},
+		{"feat:", changelog.SkipReasonNoGroup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			f := newFixture(t)
+			f.commit("feat: kept")
+			f.commit(tt.message)
+
+			cl, err := changelog.BuildRepository(f.repo, changelog.Options{ReportSkipped: true})
+			if err != nil {
+				t.Fatalf("cannot build changelog: %v", err)
+			}
+			if len(cl.Skipped) != 1 || cl.Skipped[0].Reason != tt.reason {
+				t.Errorf("got %d skipped commits, want only %q skipped with reason %q", len(cl.Skipped), tt.message, tt.reason)
+			}
+			md, err := cl.Render()
+			if err != nil {
+				t.Fatalf("cannot render changelog: %v", err)
+			}
+			want := "# Changelog\n\n## [unreleased]\n\n### ✨ Features\n\n- Kept\n"
+			if md != want {
+				t.Errorf("changelog mismatch\ngot:\n%s\nwant:\n%s", md, want)
+			}
+		})
+	}
+}
+
+func TestBuildRepositoryInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts changelog.Options
+		want string
+	}{
+		{"limit", changelog.Options{Limit: -1}, "invalid limit -1"},
+		{"max per group", changelog.Options{MaxPerGroup: -1}, "invalid max per group -1"},
+		{"capitalization", changelog.Options{Capitalize: "upper"}, `invalid capitalization "upper"`},
+		{"sort", changelog.Options{SortBy: "name"}, `invalid sort "name"`},
+		{"language", changelog.Options{Language: "!!"}, `invalid language "!!"`},
+		{"unreleased", changelog.Options{UnreleasedOnly: true, NoUnreleased: true}, "unreleased only cannot be combined with no unreleased"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.commit("feat: first")
+			_, err := changelog.BuildRepository(f.repo, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
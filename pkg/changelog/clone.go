@@ -0,0 +1,38 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	log "github.com/sirupsen/logrus"
+)
+
+// isRemoteURL reports whether the repository path is the URL of a remote
+// repository, such as https://github.com/org/repo or
+// git@github.com:org/repo.git, rather than a local path. Existing local
+// paths are never remote, even if they contain a colon, such as C:\repo.
+func isRemoteURL(path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return false
+	}
+	endpoint, err := transport.NewEndpoint(path)
+	return err == nil && endpoint.Protocol != "file"
+}
+
+// cloneRepository clones the remote repository at the URL, with all its
+// tags, into memory. HTTP credentials are taken from the URL, and SSH keys
+// from the SSH agent.
+func cloneRepository(url string) (*git.Repository, error) {
+	log.Debugf("Cloning repository %q into memory", url)
+	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:  url,
+		Tags: git.AllTags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot clone repository: %w", err)
+	}
+	return repo, nil
+}
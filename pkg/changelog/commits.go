@@ -0,0 +1,382 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultBranches are tried, in order, when HEAD cannot be resolved.
+var defaultBranches = []plumbing.ReferenceName{
+	plumbing.NewBranchReferenceName("main"),
+	plumbing.NewBranchReferenceName("master"),
+}
+
+// resolveHead returns the commit hash HEAD points to. In bare repositories,
+// HEAD may point to a branch that does not exist; in that case, the default
+// branch is used instead: main, master, or the only branch of the
+// repository.
+func resolveHead(repo *git.Repository) (plumbing.Hash, error) {
+	head, err := repo.Head()
+	if err == nil {
+		return head.Hash(), nil
+	}
+	if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return plumbing.ZeroHash, fmt.Errorf("cannot resolve HEAD: %w", err)
+	}
+
+	for _, name := range defaultBranches {
+		ref, err := repo.Reference(name, true)
+		if err == nil {
+			log.Debugf("HEAD cannot be resolved, using branch %q", name.Short())
+			return ref.Hash(), nil
+		}
+	}
+
+	branches, err := repo.Branches()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("cannot fetch branches: %w", err)
+	}
+	var refs []*plumbing.Reference
+	_ = branches.ForEach(func(ref *plumbing.Reference) error {
+		refs = append(refs, ref)
+		return nil
+	})
+	if len(refs) == 0 {
+		// The repository has no commits yet.
+		return plumbing.ZeroHash, nil
+	}
+	if len(refs) == 1 {
+		log.Debugf("HEAD cannot be resolved, using branch %q", refs[0].Name().Short())
+		return refs[0].Hash(), nil
+	}
+
+	return plumbing.ZeroHash, errors.New("cannot resolve HEAD or a default branch")
+}
+
+// resolveBranch returns the commit hash the branch points to. The name is
+// looked up as a local branch first, then as a remote-tracking branch such
+// as "origin/main", then as a branch of the origin remote, as in clones.
+func resolveBranch(repo *git.Repository, name string) (plumbing.Hash, error) {
+	for _, refName := range []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName(name),
+		plumbing.ReferenceName("refs/remotes/" + name),
+		plumbing.NewRemoteReferenceName("origin", name),
+	} {
+		ref, err := repo.Reference(refName, true)
+		if err == nil {
+			return ref.Hash(), nil
+		}
+		if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return plumbing.ZeroHash, fmt.Errorf("cannot resolve branch %q: %w", name, err)
+		}
+	}
+	return plumbing.ZeroHash, fmt.Errorf("branch %q does not exist", name)
+}
+
+// resolveRef returns a reference to the commit the revision resolves to.
+func (g *generator) resolveRef(name string) (*plumbing.Reference, error) {
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(name))
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve ref %q: %w", name, err)
+	}
+	return plumbing.NewHashReference(plumbing.ReferenceName(name), *hash), nil
+}
+
+// isAncestorCommit reports whether the commit is reachable from the head
+// commit, including the head commit itself, through any parent of merge
+// commits.
+func (g *generator) isAncestorCommit(c *object.Commit) (bool, error) {
+	if c.Hash == g.head {
+		return true, nil
+	}
+	head, err := g.repo.CommitObject(g.head)
+	if err != nil {
+		return false, fmt.Errorf("cannot retrieve head commit: %w", err)
+	}
+	ancestor, err := c.IsAncestor(head)
+	if err != nil {
+		return false, fmt.Errorf("cannot check ancestry of commit %s: %w", c.Hash, err)
+	}
+	return ancestor, nil
+}
+
+// release adds the commits reachable from the tag to the commits of the
+// versions built so far, with AllBranches.
+func (g *generator) release(tag *plumbing.Reference) error {
+	if !g.opts.AllBranches || tag == nil {
+		return nil
+	}
+	c, err := g.getTagCommit(tag)
+	if err != nil {
+		return err
+	}
+	if g.released[c.Hash] {
+		return nil
+	}
+	reachable, err := g.reachableFrom(c)
+	if err != nil {
+		return err
+	}
+	if g.released == nil {
+		g.released = make(map[plumbing.Hash]bool, len(reachable))
+	}
+	for hash := range reachable {
+		g.released[hash] = true
+	}
+	return nil
+}
+
+// reachableFrom returns the set of commits reachable from the commit,
+// including itself. The set computed by the previous call is reused when
+// its starting commit is an ancestor of this one, so that consecutive tags
+// only walk the commits between them. The returned set is the cache itself,
+// extended in place by the next call: it must not be kept or modified.
+func (g *generator) reachableFrom(from *object.Commit) (map[plumbing.Hash]bool, error) {
+	prev, prevFrom := g.rangeCache, g.rangeCacheFrom
+	if from.Hash == prevFrom {
+		return prev, nil
+	}
+	if prev[from.Hash] {
+		// The commit is an ancestor of the previous one, whose set is a
+		// superset of the one to compute.
+		prev = nil
+	}
+
+	reachable, complete, err := g.walkUntil(from, prev, prevFrom)
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		for hash := range reachable {
+			prev[hash] = true
+		}
+		reachable = prev
+	} else if prev != nil {
+		// The previous commit is not an ancestor: walk the full history.
+		reachable, _, err = g.walkUntil(from, nil, plumbing.ZeroHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g.rangeCache, g.rangeCacheFrom = reachable, from.Hash
+	return reachable, nil
+}
+
+// walkUntil returns the commits reachable from the commit without
+// descending into the commits of the known set. It also reports whether
+// the known set was reached through its starting commit knownFrom, in
+// which case the returned commits and the known set together are exactly
+// the commits reachable from the commit.
+func (g *generator) walkUntil(from *object.Commit, known map[plumbing.Hash]bool, knownFrom plumbing.Hash) (map[plumbing.Hash]bool, bool, error) {
+	visited := map[plumbing.Hash]bool{from.Hash: true}
+	reachedKnown := false
+	queue := []*object.Commit{from}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, hash := range c.ParentHashes {
+			if visited[hash] {
+				continue
+			}
+			if known[hash] {
+				reachedKnown = reachedKnown || hash == knownFrom
+				continue
+			}
+			visited[hash] = true
+			parent, err := g.repo.CommitObject(hash)
+			if err != nil {
+				return nil, false, fmt.Errorf("cannot retrieve commit %s: %w", hash, err)
+			}
+			queue = append(queue, parent)
+		}
+	}
+	return visited, reachedKnown, nil
+}
+
+// getCommitsInRange returns the commits reachable from newerTag (or the head
+// commit if newerTag is nil) but not from olderTag, in the order of the
+// history. If Paths are set, only commits touching at least one of them are
+// returned.
+func (g *generator) getCommitsInRange(olderTag, newerTag *plumbing.Reference) ([]*object.Commit, error) {
+	var fromReachable map[plumbing.Hash]bool
+	if olderTag != nil {
+		from, err := g.getTagCommit(olderTag)
+		if err != nil {
+			return nil, err
+		}
+		fromReachable, err = g.reachableFrom(from)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if g.released != nil {
+		// With AllBranches, the older tag may be on another branch: the
+		// commits of all the older versions are left out so that none is
+		// listed twice.
+		fromReachable = g.released
+	}
+
+	var until *object.Commit
+	var err error
+	if newerTag != nil {
+		until, err = g.getTagCommit(newerTag)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		until, err = g.repo.CommitObject(g.head)
+		if err != nil {
+			return nil, fmt.Errorf("cannot retrieve head commit: %w", err)
+		}
+	}
+
+	// The commits reachable from olderTag are pruned from the walk rather
+	// than ending it, as the other parents of a merge commit may lead to
+	// commits that are not.
+	commitIter := object.NewCommitPreorderIter(until, fromReachable, nil)
+
+	var commits []*object.Commit
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if !g.opts.IncludeMerges && c.NumParents() > 1 {
+			return nil
+		}
+
+		if g.isExcludedAuthor(c) {
+			return nil
+		}
+
+		if len(g.opts.Paths) > 0 {
+			touches, err := commitTouchesPaths(c, g.opts.Paths)
+			if err != nil {
+				return err
+			}
+			if !touches {
+				return nil
+			}
+		}
+
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return commits, nil
+}
+
+// commitTouchesPaths reports whether the commit changes a file within any
+// of the given paths, compared to its first parent.
+func commitTouchesPaths(c *object.Commit, paths []string) (bool, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return false, fmt.Errorf("cannot retrieve tree of commit %s: %w", c.Hash, err)
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return false, fmt.Errorf("cannot retrieve parent of commit %s: %w", c.Hash, err)
+		}
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return false, fmt.Errorf("cannot retrieve tree of commit %s: %w", parent.Hash, err)
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return false, fmt.Errorf("cannot diff commit %s: %w", c.Hash, err)
+	}
+
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" && pathMatches(name, paths) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// pathMatches reports whether the file is one of the paths or lies within
+// one of them.
+func pathMatches(file string, paths []string) bool {
+	for _, p := range paths {
+		p = strings.Trim(filepath.ToSlash(filepath.Clean(p)), "/")
+		if p == "." || p == "" || file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// compileAuthorPattern compiles an author pattern to a case-insensitive
+// regex. A pattern without wildcards matches a substring.
+func compileAuthorPattern(pattern string) *regexp.Regexp {
+	if !strings.ContainsAny(pattern, "*?") {
+		pattern = "*" + pattern + "*"
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(expr)
+	return regexp.MustCompile("(?i)^" + expr + "$")
+}
+
+// isExcludedAuthor reports whether the commit author matches one of the
+// excluded author patterns.
+func (g *generator) isExcludedAuthor(c *object.Commit) bool {
+	for _, re := range g.excludeAuthors {
+		if re.MatchString(c.Author.Name) || re.MatchString(c.Author.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// getTagCommit returns the commit the tag points to.
+func (g *generator) getTagCommit(tag *plumbing.Reference) (*object.Commit, error) {
+	var commit *object.Commit
+	// Step 1: Resolve the Tag to a Commit
+	// Dereference the tag to get the commit it is pointing to
+	obj, err := g.repo.TagObject(tag.Hash())
+	if err != nil {
+		// The tag might be a lightweight tag,
+		// not an annotated tag. In this case,
+		// it directly points to a commit.
+		commit, err = g.repo.CommitObject(tag.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("cannot retrieve commit from tag %s: %w", tag.Name().Short(), err)
+		}
+	} else {
+		// The tag is an annotated tag, so we need to
+		// further resolve the object it is pointing to.
+		commit, err = obj.Commit()
+		if err != nil {
+			return nil, fmt.Errorf("cannot retrieve commit from tag object %s: %w", tag.Name().Short(), err)
+		}
+	}
+
+	return commit, nil
+}
+
+// getTagMessage returns the trimmed message of an annotated tag, or an
+// empty string for a lightweight tag.
+func (g *generator) getTagMessage(tag *plumbing.Reference) string {
+	obj, err := g.repo.TagObject(tag.Hash())
+	if err != nil {
+		// Lightweight tags point directly to a commit and have no message.
+		return ""
+	}
+	return strings.TrimSpace(obj.Message)
+}
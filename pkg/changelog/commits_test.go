@@ -0,0 +1,177 @@
+package changelog_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestAncestorTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(f *fixture)
+		want  int
+	}{
+		{
+			name:  "diamond",
+			setup: (*fixture).diamond,
+			// All but v9.0.0.
+			want: 4,
+		},
+		{
+			name: "maintenance branches",
+			setup: func(f *fixture) {
+				f.maintenance(100)
+				f.checkout("stray", "v0.50.0")
+				f.commit("feat: stray")
+				f.tag("v1.0.0")
+				f.checkout("head", "main-100")
+			},
+			// The main line and the merged patches.
+			want: 105,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			tt.setup(f)
+			got, err := changelog.AncestorTags(f.repo, changelog.Options{}, false)
+			if err != nil {
+				t.Fatalf("cannot check ancestors: %v", err)
+			}
+			want, err := changelog.AncestorTags(f.repo, changelog.Options{}, true)
+			if err != nil {
+				t.Fatalf("cannot walk log: %v", err)
+			}
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("got ancestor tags %q, want %q", got, want)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %d ancestor tags, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkAncestorTags(b *testing.B) {
+	f := newLargeFixture(b, 300)
+	for _, walkLog := range []bool{false, true} {
+		name := "IsAncestor"
+		if walkLog {
+			name = "log"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := changelog.AncestorTags(f.repo, changelog.Options{}, walkLog); err != nil {
+					b.Fatalf("cannot check ancestors: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestReachableSets(t *testing.T) {
+	f := newLargeFixture(t, 50)
+	// Forward along the main line and across the maintenance branches,
+	// then back to ancestors of the previous revisions.
+	var revs []string
+	for i := 1; i <= 50; i++ {
+		revs = append(revs, fmt.Sprintf("v0.%d.0", i))
+		if i%10 == 0 {
+			revs = append(revs, fmt.Sprintf("v0.%d.1", i))
+		}
+	}
+	revs = append(revs, "HEAD", "v0.20.1", "v0.5.0", "v0.30.0", "HEAD", "HEAD")
+
+	got, err := changelog.ReachableSets(f.repo, revs, true)
+	if err != nil {
+		t.Fatalf("cannot compute cached reachable sets: %v", err)
+	}
+	want, err := changelog.ReachableSets(f.repo, revs, false)
+	if err != nil {
+		t.Fatalf("cannot compute reachable sets: %v", err)
+	}
+	for i, rev := range revs {
+		if strings.Join(got[i], " ") != strings.Join(want[i], " ") {
+			t.Errorf("got %d commits reachable from %s (#%d), want %d", len(got[i]), rev, i, len(want[i]))
+		}
+	}
+}
+
+func TestTagRanges(t *testing.T) {
+	f := newLargeFixture(t, 20)
+	f.commit("feat: unreleased")
+
+	// The unmerged patch 0.10.1 is left out, and the merged patch 0.20.1
+	// comes between 0.20.0 and the unreleased changes.
+	var want []string
+	for i := 1; i <= 20; i++ {
+		want = append(want, fmt.Sprintf("feat: change %d", i))
+	}
+	want = append(want, "fix: patch 20", "feat: unreleased")
+
+	for _, cached := range []bool{true, false} {
+		ranges, err := changelog.TagRanges(f.repo, changelog.Options{}, cached)
+		if err != nil {
+			t.Fatalf("cannot compute ranges: %v", err)
+		}
+		var got []string
+		for _, hashes := range ranges {
+			var messages []string
+			for _, hash := range hashes {
+				c, err := f.repo.CommitObject(plumbing.NewHash(hash))
+				if err != nil {
+					t.Fatalf("cannot retrieve commit %s: %v", hash, err)
+				}
+				messages = append(messages, c.Message)
+			}
+			got = append(got, strings.Join(messages, ", "))
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("cached %v: got ranges %q, want %q", cached, got, want)
+		}
+	}
+}
+
+func TestTagRangesCached(t *testing.T) {
+	f := newLargeFixture(t, 100)
+	f.commit("feat: unreleased")
+
+	got, err := changelog.TagRanges(f.repo, changelog.Options{}, true)
+	if err != nil {
+		t.Fatalf("cannot compute cached ranges: %v", err)
+	}
+	want, err := changelog.TagRanges(f.repo, changelog.Options{}, false)
+	if err != nil {
+		t.Fatalf("cannot compute ranges: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d ranges, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if strings.Join(got[i], " ") != strings.Join(want[i], " ") {
+			t.Errorf("range #%d: got commits %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func BenchmarkTagRanges(b *testing.B) {
+	f := newLargeFixture(b, 300)
+	for _, cached := range []bool{true, false} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := changelog.TagRanges(f.repo, changelog.Options{}, cached); err != nil {
+					b.Fatalf("cannot compute ranges: %v", err)
+				}
+			}
+		})
+	}
+}
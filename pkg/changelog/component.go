@@ -0,0 +1,113 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// compileTagPattern compiles the TagPattern, which must have a "version"
+// named group.
+func compileTagPattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag pattern %q: %w", pattern, err)
+	}
+	if re.SubexpIndex("version") < 0 {
+		return nil, fmt.Errorf("tag pattern %q has no version group", pattern)
+	}
+	return re, nil
+}
+
+// components returns the sorted components of the version tags reachable
+// from the head commit, or of all the version tags with AllBranches.
+func (g *generator) components() ([]string, error) {
+	tags, err := g.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch tags: %w", err)
+	}
+
+	set := make(map[string]bool)
+	err = tags.ForEach(func(tag *plumbing.Reference) error {
+		_, component, ok := g.tagVersion(tag.Name().Short())
+		if !ok || set[component] {
+			return nil
+		}
+		commit, err := g.getTagCommit(tag)
+		if err != nil {
+			return err
+		}
+		ancestor, err := g.isAncestorCommit(commit)
+		if err != nil {
+			return err
+		}
+		set[component] = ancestor || g.opts.AllBranches
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot iterate tags: %w", err)
+	}
+
+	components := make([]string, 0, len(set))
+	for component, reachable := range set {
+		if !reachable {
+			continue
+		}
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	return components, nil
+}
+
+// buildComponents returns the changelog combining the versions of each
+// component of the tags matched by TagPattern. With several components,
+// the versions are interleaved by date like with several repositories, and
+// their labels are preceded by their component.
+func (g *generator) buildComponents() (*Changelog, error) {
+	if g.opts.Component != "" {
+		return g.build()
+	}
+	components, err := g.components()
+	if err != nil {
+		return nil, err
+	}
+	if len(components) <= 1 {
+		if len(components) == 1 {
+			g.component = components[0]
+		}
+		return g.build()
+	}
+
+	cl := &Changelog{Options: g.opts, RepoURL: g.repoURL}
+	// The commits of the components overlap: each skipped commit is
+	// reported once.
+	skipped := make(map[string]bool)
+	for _, component := range components {
+		cg, err := newGenerator(g.repo, g.opts)
+		if err != nil {
+			return nil, err
+		}
+		cg.component = component
+		componentChangelog, err := cg.build()
+		if err != nil {
+			return nil, fmt.Errorf("component %q: %w", component, err)
+		}
+		for _, version := range componentChangelog.Versions {
+			version.Component = component
+			version.Label = component + " " + version.Label
+			cg.setHeader(version)
+		}
+		cl.Versions = append(cl.Versions, componentChangelog.Versions...)
+		for _, c := range componentChangelog.Skipped {
+			if !skipped[c.Hash] {
+				skipped[c.Hash] = true
+				cl.Skipped = append(cl.Skipped, c)
+			}
+		}
+	}
+
+	sortVersions(cl.Versions, g.opts.Order)
+	return cl, nil
+}
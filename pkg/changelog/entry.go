@@ -0,0 +1,687 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// unscopedTitle is the title of the commits without a scope when grouping
+// by scope.
+const unscopedTitle = "Other"
+
+// coAuthorRegex matches a co-author trailer and captures the name and email.
+var coAuthorRegex = regexp.MustCompile(`(?i)^co-authored-by:\s*(.*?)\s*<([^>]+)>\s*$`)
+
+// trailerRegex matches the start of a git trailer, such as "Refs: " or
+// "Fixes #", which ends the text of a breaking change footer.
+var trailerRegex = regexp.MustCompile(`^[A-Za-z][\w-]*(?:: | #)`)
+
+// reviewerRegex matches a reviewer trailer and captures the name and the
+// optional email.
+var reviewerRegex = regexp.MustCompile(`(?i)^reviewed-by:\s*(.*?)\s*(?:<([^>]+)>)?\s*$`)
+
+// gitHubNoreplyDomain is the domain of the emails GitHub generates to keep
+// the email of its users private.
+const gitHubNoreplyDomain = "users.noreply.github.com"
+
+// prRefRegex matches a pull request reference in a commit title, such as
+// the "(#123)" GitHub appends to squash merges, with the spaces before it.
+var prRefRegex = regexp.MustCompile(`\s*\(#(\d+)\)`)
+
+// leadingEmojiRegex matches a leading emoji, or a gitmoji shortcode such as
+// ":sparkles:", and the spaces following it.
+var leadingEmojiRegex = regexp.MustCompile(`^(?::[a-z0-9_+-]+:|[\p{So}\p{Sk}\p{Mn}\x{200D}]+)\s*`)
+
+// issueRefRegex matches issue references such as "#123" or "Closes #123".
+var issueRefRegex = regexp.MustCompile(`(?:^|[^\w&/])#(\d+)\b`)
+
+// getTagEntryDetails returns a version holding the breaking changes and
+// the grouped commits between olderTag and newerTag.
+func (g *generator) getTagEntryDetails(olderTag, newerTag *plumbing.Reference) (*Version, error) {
+	commits, err := g.getCommitsInRange(olderTag, newerTag)
+	if err != nil {
+		return nil, err
+	}
+	if g.opts.FoldReverts {
+		commits = foldReverts(commits)
+	}
+
+	groupedCommits := make(map[string][]*Commit)
+	var breakingChanges []*Commit
+	var count int
+	// seen holds the entries of each section, to leave out duplicates.
+	seen := make(map[string]map[string]bool)
+
+	for _, c := range commits {
+		// Only print the first line of the commit message (the title)
+		title := g.commitTitle(c.Message)
+		if g.isIgnoredTitle(title) {
+			g.skip(c, title, SkipReasonIgnored)
+			continue
+		}
+
+		var commit *Commit
+		var section string
+		matched := false
+		for _, group := range g.groups {
+			matches := group.re.FindStringSubmatch(title)
+
+			if len(matches) > 0 {
+				matched = true
+				if group.Skip {
+					g.skip(c, title, SkipReasonSkipGroup+" "+group.Message)
+					break
+				}
+				if !g.isIncludedType(commitType(title)) {
+					g.skip(c, title, SkipReasonExcludedType)
+					break
+				}
+
+				commit = &Commit{
+					Hash:     c.Hash.String(),
+					URL:      g.getCommitURL(c.Hash.String()),
+					Type:     commitType(title),
+					Breaking: matches[group.re.SubexpIndex("breaking")] != "" || g.hasBreakingFooter(c.Message),
+					Author:   c.Author.Name,
+					Date:     g.commitDate(c),
+				}
+				if rawScope := matches[group.re.SubexpIndex("scope")]; rawScope != "" {
+					// Remove the parentheses from the captured scope
+					rawScope = strings.TrimSuffix(strings.TrimPrefix(rawScope, "("), ")")
+					commit.Scope = strings.ToLower(rawScope)
+				}
+				// Remove prefix from the title
+				commit.Description = g.capitalize(group.re.ReplaceAllString(title, ""))
+				section = group.Group
+				break
+			}
+		}
+		if !matched && g.opts.Strict {
+			g.unmatched = append(g.unmatched, c)
+		}
+		if !matched && g.opts.CatchAll != "" && g.includeTypes == nil {
+			commit = &Commit{
+				Hash:        c.Hash.String(),
+				URL:         g.getCommitURL(c.Hash.String()),
+				Description: g.capitalize(title),
+				Author:      c.Author.Name,
+				Date:        g.commitDate(c),
+			}
+			section = g.opts.CatchAll
+		} else if !matched {
+			g.skip(c, title, SkipReasonNoGroup)
+			if g.opts.CountAll {
+				count++
+			}
+		}
+		if commit == nil {
+			continue
+		}
+		if commit.Description == "" {
+			log.Debugf("Skipping commit %s without description", c.Hash)
+			g.skip(c, title, SkipReasonEmptyDescription)
+			continue
+		}
+		if len(g.scopes) > 0 && !g.scopes[commit.Scope] {
+			g.skip(c, title, SkipReasonExcludedScope)
+			continue
+		}
+		if g.opts.RequireScope && commit.Scope == "" && !commit.Breaking {
+			g.skip(c, title, SkipReasonNoScope)
+			continue
+		}
+
+		if g.opts.PRURL != "" {
+			commit.Description, commit.PR = extractPRRef(commit.Description)
+		}
+		if g.opts.ShowIssues {
+			commit.Issues = issueRefs(c.Message, commit.PR)
+		}
+		if g.opts.BreakingNotes && commit.Breaking {
+			commit.BreakingNote = g.breakingNote(c.Message)
+		}
+		if g.opts.IncludeBody {
+			commit.Body = g.commitBody(c.Message)
+		}
+		if g.opts.ShowAuthors {
+			commit.Authors = g.commitAuthors(c)
+		}
+		if g.opts.ShowReviewers {
+			commit.Reviewers = g.commitReviewers(c)
+		}
+		if g.opts.VerifySignatures {
+			commit.Signed = c.PGPSignature != ""
+			commit.Verified = g.verifySignature(c)
+		}
+		commit.Entry = g.commitEntry(commit)
+
+		key := commit.Entry
+		if commit.Breaking {
+			section, key = breakingGroup, commit.Type+": "+commit.Entry
+		}
+		if !g.opts.KeepDuplicates {
+			if seen[section][key] {
+				log.Debugf("Skipping duplicate entry %q of commit %s", key, c.Hash)
+				g.skip(c, title, SkipReasonDuplicate)
+				continue
+			}
+			if seen[section] == nil {
+				seen[section] = make(map[string]bool)
+			}
+			seen[section][key] = true
+		}
+		count++
+
+		if commit.Breaking {
+			breakingChanges = append(breakingChanges, commit)
+		} else {
+			groupedCommits[section] = append(groupedCommits[section], commit)
+		}
+	}
+
+	version := &Version{CommitCount: count}
+	if g.opts.Stats {
+		version.Stats, err = diffStats(commits)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(breakingChanges) > 0 {
+		version.Breaking = g.newGroup(breakingGroup, breakingChanges)
+	}
+	// Several groups may share a title, such as feat and perf under
+	// "Improvements", whose commits are then listed in a single section at
+	// the position of the first one.
+	emitted := make(map[string]bool)
+	for _, group := range g.groups {
+		if group.Skip || emitted[group.Group] {
+			continue
+		}
+		if commits := groupedCommits[group.Group]; len(commits) > 0 || g.opts.ShowEmptyGroups {
+			version.Groups = append(version.Groups, g.newGroup(group.Group, commits))
+			emitted[group.Group] = true
+		}
+	}
+	if commits := groupedCommits[g.opts.CatchAll]; g.opts.CatchAll != "" && !emitted[g.opts.CatchAll] && (len(commits) > 0 || g.opts.ShowEmptyGroups) {
+		version.Groups = append(version.Groups, g.newGroup(g.opts.CatchAll, commits))
+	}
+	if g.opts.Compact {
+		version.Summary = g.compactSummary(groupedCommits, len(breakingChanges))
+	}
+	return version, nil
+}
+
+// compactSummary returns the number of commits of each type, in the order
+// of the groups, followed by the number of breaking changes in bold, such
+// as "3 feat, 5 fix, **1 breaking**". The commits of the catch-all group are
+// counted as "other".
+func (g *generator) compactSummary(groupedCommits map[string][]*Commit, breaking int) string {
+	titles := make([]string, 0, len(g.groups)+1)
+	for _, group := range g.groups {
+		titles = append(titles, group.Group)
+	}
+	if g.opts.CatchAll != "" {
+		titles = append(titles, g.opts.CatchAll)
+	}
+
+	var types []string
+	counts := make(map[string]int)
+	done := make(map[string]bool)
+	for _, title := range titles {
+		if done[title] {
+			continue
+		}
+		done[title] = true
+		for _, c := range groupedCommits[title] {
+			typ := c.Type
+			if typ == "" {
+				typ = "other"
+			}
+			if counts[typ] == 0 {
+				types = append(types, typ)
+			}
+			counts[typ]++
+		}
+	}
+
+	parts := make([]string, 0, len(types)+1)
+	for _, typ := range types {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[typ], typ))
+	}
+	if breaking > 0 {
+		parts = append(parts, fmt.Sprintf("**%d breaking**", breaking))
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// commitTitle returns the first line of the commit message, without its
+// leading emoji if AllowLeadingEmoji is set.
+func (g *generator) commitTitle(message string) string {
+	title := strings.Split(message, "\n")[0]
+	if g.opts.AllowLeadingEmoji {
+		title = leadingEmojiRegex.ReplaceAllString(title, "")
+	}
+	return title
+}
+
+// skip records the commit as left out of the changelog for the reason, if
+// ReportSkipped is set.
+func (g *generator) skip(c *object.Commit, title, reason string) {
+	if g.opts.ReportSkipped {
+		g.skipped = append(g.skipped, &SkippedCommit{Hash: c.Hash.String(), Title: title, Reason: reason})
+	}
+}
+
+// capitalize returns the text with its first word capitalized according
+// to Capitalize and Language and its whitespace collapsed, or an empty
+// string if the text is blank. A first word without cased letters, such as
+// one in Chinese or Arabic, is left as written.
+func (g *generator) capitalize(text string) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return ""
+	}
+	if !hasCasedLetter(words[0]) {
+		return strings.Join(words, " ")
+	}
+	switch g.opts.Capitalize {
+	case CapitalizeFirstWord:
+		words[0] = cases.Title(g.language, cases.NoLower).String(words[0])
+	case CapitalizeSentence:
+		r, size := utf8.DecodeRuneInString(words[0])
+		if g.language == language.Und {
+			words[0] = string(unicode.ToUpper(r)) + words[0][size:]
+		} else {
+			words[0] = cases.Upper(g.language).String(string(r)) + words[0][size:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// hasCasedLetter reports whether the word has a letter with distinct upper
+// and lower cases, as in the Latin, Greek or Cyrillic scripts.
+func hasCasedLetter(word string) bool {
+	for _, r := range word {
+		if unicode.IsUpper(r) || unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// newGroup returns the group of the commits, sorted according to
+// CommitOrder, truncated to MaxPerGroup commits and grouped by scope if
+// GroupByScope is set.
+func (g *generator) newGroup(title string, commits []*Commit) *Group {
+	switch g.opts.CommitOrder {
+	case OrderAsc:
+		sort.SliceStable(commits, func(i, j int) bool {
+			return commits[i].Date.Before(commits[j].Date)
+		})
+	case OrderDesc:
+		sort.SliceStable(commits, func(i, j int) bool {
+			return commits[i].Date.After(commits[j].Date)
+		})
+	}
+
+	group := &Group{Title: g.groupTitle(title), Commits: commits}
+	if max := g.opts.MaxPerGroup; max > 0 && len(commits) > max {
+		group.Commits, group.Omitted = commits[:max], len(commits)-max
+		commits = group.Commits
+	}
+	if !g.opts.GroupByScope {
+		return group
+	}
+
+	scopes := make(map[string]*Scope)
+	var unscoped *Scope
+	for _, c := range commits {
+		if c.Scope == "" {
+			if unscoped == nil {
+				unscoped = &Scope{Name: unscopedTitle}
+			}
+			unscoped.Commits = append(unscoped.Commits, c)
+			continue
+		}
+		scope, ok := scopes[c.Scope]
+		if !ok {
+			scope = &Scope{Name: c.Scope}
+			scopes[c.Scope] = scope
+			group.Scopes = append(group.Scopes, scope)
+		}
+		scope.Commits = append(scope.Commits, c)
+	}
+	sort.Slice(group.Scopes, func(i, j int) bool {
+		return group.Scopes[i].Name < group.Scopes[j].Name
+	})
+	if unscoped != nil {
+		group.Scopes = append(group.Scopes, unscoped)
+	}
+	return group
+}
+
+// commitEntry returns the Markdown list entry of the commit: its hash,
+// scope, description, issues, authors, signature warning and body, as
+// enabled by the options.
+func (g *generator) commitEntry(c *Commit) string {
+	var parts []string
+	if g.opts.ShowHash {
+		hash := fmt.Sprintf("`%s`", c.Hash[:7])
+		if c.URL != "" {
+			hash = fmt.Sprintf("[%s](%s)", hash, c.URL)
+		}
+		parts = append(parts, "("+hash+")")
+	}
+	if c.Scope != "" && !g.opts.GroupByScope {
+		parts = append(parts, fmt.Sprintf("(**%s**)", c.Scope))
+	}
+	parts = append(parts, c.Description)
+	if c.PR != "" {
+		pr := strings.ReplaceAll(g.opts.PRURL, "{id}", c.PR)
+		parts = append(parts, fmt.Sprintf("([#%s](%s))", c.PR, pr))
+	}
+	if refs := g.formatIssueRefs(c.Issues); refs != "" {
+		parts = append(parts, refs)
+	}
+	if authors := formatAuthors(c.Authors); authors != "" {
+		parts = append(parts, authors)
+	}
+	if len(c.Reviewers) > 0 {
+		parts = append(parts, "(reviewed by "+strings.Join(authorNames(c.Reviewers), ", ")+")")
+	}
+	if g.opts.VerifySignatures && !c.Verified {
+		if c.Signed {
+			parts = append(parts, "(⚠️ unverified signature)")
+		} else {
+			parts = append(parts, "(⚠️ unsigned)")
+		}
+	}
+	entry := strings.Join(parts, " ")
+	indent := "\n  "
+	if g.opts.GroupByScope {
+		// The entry is nested under its scope.
+		indent += "  "
+	}
+	if c.BreakingNote != "" {
+		entry += indent + "> " + c.BreakingNote
+		if len(c.Body) > 0 {
+			entry += indent + ">"
+		}
+	}
+	for _, line := range c.Body {
+		entry += strings.TrimRight(indent+"> "+line, " ")
+	}
+	return entry
+}
+
+// groupTitle returns the title of the group as rendered in its header,
+// with its leading emoji replaced according to Emoji, or removed if NoEmoji
+// is set.
+func (g *generator) groupTitle(title string) string {
+	emoji, ok := g.groupEmoji[title]
+	if !g.opts.NoEmoji && !ok {
+		return title
+	}
+	title = strings.TrimLeftFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if g.opts.NoEmoji || emoji == "" {
+		return title
+	}
+	return emoji + " " + title
+}
+
+// hasBreakingFooter reports whether the body of the commit message has a
+// breaking change footer.
+func (g *generator) hasBreakingFooter(message string) bool {
+	lines := strings.Split(message, "\n")
+	for _, line := range lines[1:] {
+		if g.isBreakingFooter(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBreakingFooter reports whether the line is a breaking change footer.
+func (g *generator) isBreakingFooter(line string) bool {
+	return g.breakingKeyword(line) != ""
+}
+
+// breakingKeyword returns the breaking change keyword the line starts
+// with, ignoring case and leading spaces, or an empty string.
+func (g *generator) breakingKeyword(line string) string {
+	line = strings.ToLower(strings.TrimSpace(line))
+	for _, keyword := range g.breakingKeywords {
+		if strings.HasPrefix(line, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// breakingNote returns the text of the breaking change footers of the
+// commit message, without their keyword and with their continuation lines
+// joined, up to a blank line or another trailer.
+func (g *generator) breakingNote(message string) string {
+	var words []string
+	inFooter := false
+	for _, line := range strings.Split(message, "\n")[1:] {
+		line = strings.TrimSpace(line)
+		if keyword := g.breakingKeyword(line); keyword != "" {
+			inFooter = true
+			words = append(words, strings.Fields(line[len(keyword):])...)
+			continue
+		}
+		if line == "" || trailerRegex.MatchString(line) {
+			inFooter = false
+		}
+		if inFooter {
+			words = append(words, strings.Fields(line)...)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// issueRefs returns the numbers of the issues referenced in the commit
+// message, title included, without duplicates nor the pull request pr, if
+// any, already linked from the title.
+func issueRefs(message, pr string) []string {
+	var ids []string
+	seen := map[string]bool{pr: true}
+	for _, line := range strings.Split(message, "\n") {
+		for _, match := range issueRefRegex.FindAllStringSubmatch(line, -1) {
+			id := match[1]
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// extractPRRef returns the description without its last pull request
+// reference, and the number of the pull request, or the description as is
+// and an empty string if it has none or nothing else.
+func extractPRRef(description string) (string, string) {
+	matches := prRefRegex.FindAllStringSubmatchIndex(description, -1)
+	if len(matches) == 0 {
+		return description, ""
+	}
+	m := matches[len(matches)-1]
+	rest := strings.TrimSpace(description[:m[0]] + description[m[1]:])
+	if rest == "" {
+		return description, ""
+	}
+	return rest, description[m[2]:m[3]]
+}
+
+// formatIssueRefs returns the issues as a parenthesized list of links, or
+// an empty string if there are none.
+func (g *generator) formatIssueRefs(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	refs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		ref := "#" + id
+		if issueURL := g.getIssueURL(id); issueURL != "" {
+			ref = fmt.Sprintf("[%s](%s)", ref, issueURL)
+		}
+		refs = append(refs, ref)
+	}
+	return "(" + strings.Join(refs, ", ") + ")"
+}
+
+// commitAuthors returns the author of the commit followed by the co-authors
+// of its trailers, without duplicates.
+func (g *generator) commitAuthors(c *object.Commit) []*Author {
+	authors := []*Author{g.newAuthor(c.Author.Name, c.Author.Email)}
+	seen := map[string]bool{strings.ToLower(c.Author.Email): true}
+	for _, line := range strings.Split(c.Message, "\n")[1:] {
+		match := coAuthorRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || seen[strings.ToLower(match[2])] {
+			continue
+		}
+		seen[strings.ToLower(match[2])] = true
+		authors = append(authors, g.newAuthor(match[1], match[2]))
+	}
+	return authors
+}
+
+// newAuthor returns the author with the handle mapped to its email, if
+// any, or else the GitHub username of its noreply email if GitHubHandles is
+// set.
+func (g *generator) newAuthor(name, email string) *Author {
+	author := &Author{Name: name, Email: email, Handle: g.authorHandles[strings.ToLower(email)]}
+	if author.Handle == "" && g.opts.GitHubHandles {
+		if username := gitHubUsername(email); username != "" {
+			author.Handle = username
+			author.URL = "https://github.com/" + username
+		}
+	}
+	return author
+}
+
+// gitHubUsername returns the GitHub username encoded in a noreply email,
+// such as "12345+octocat@users.noreply.github.com", or an empty string.
+func gitHubUsername(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || !strings.EqualFold(domain, gitHubNoreplyDomain) {
+		return ""
+	}
+	if _, username, ok := strings.Cut(local, "+"); ok {
+		return username
+	}
+	return local
+}
+
+// formatAuthors returns the authors as a parenthesized list of handles, or
+// names for the authors without a handle, or an empty string if there are
+// none.
+func formatAuthors(authors []*Author) string {
+	if len(authors) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(authorNames(authors), ", ") + ")"
+}
+
+// authorNames returns the authors by handle, linked to their profile if
+// known, or else by name.
+func authorNames(authors []*Author) []string {
+	names := make([]string, 0, len(authors))
+	for _, a := range authors {
+		if a.URL != "" {
+			names = append(names, fmt.Sprintf("[@%s](%s)", a.Handle, a.URL))
+		} else if a.Handle != "" {
+			names = append(names, "@"+a.Handle)
+		} else {
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
+
+// commitReviewers returns the reviewers of the "Reviewed-by" trailers of
+// the commit, without duplicates.
+func (g *generator) commitReviewers(c *object.Commit) []*Author {
+	var reviewers []*Author
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(c.Message, "\n")[1:] {
+		match := reviewerRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || match[1] == "" {
+			continue
+		}
+		key := strings.ToLower(match[2])
+		if key == "" {
+			key = strings.ToLower(match[1])
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		reviewers = append(reviewers, g.newAuthor(match[1], match[2]))
+	}
+	return reviewers
+}
+
+// commitBody returns the lines of the commit message body, without the
+// breaking change footers and the surrounding blank lines.
+func (g *generator) commitBody(message string) []string {
+	lines := strings.Split(message, "\n")[1:]
+
+	var body []string
+	inFooter := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if g.isBreakingFooter(line) {
+			inFooter = true
+			continue
+		}
+		if line == "" || trailerRegex.MatchString(line) {
+			inFooter = false
+		}
+		if inFooter {
+			// The continuation lines are part of the breaking change
+			// footer.
+			continue
+		}
+		if len(body) == 0 && line == "" {
+			continue
+		}
+		body = append(body, line)
+	}
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+	}
+	return body
+}
+
+// isIncludedType reports whether the commits of the type are included
+// according to ExcludeTypes and IncludeTypes.
+func (g *generator) isIncludedType(typ string) bool {
+	return !g.excludeTypes[typ] && (g.includeTypes == nil || g.includeTypes[typ])
+}
+
+// commitType returns the conventional commit type of the title, that is the
+// text before the scope, breaking change marker or colon.
+func commitType(title string) string {
+	if i := strings.IndexAny(title, "(!:"); i >= 0 {
+		title = title[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(title))
+}
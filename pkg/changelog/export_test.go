@@ -0,0 +1,161 @@
+package changelog
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// AncestorTags returns the names of the tags of the repository whose commit
+// is reachable from its head, sorted, as reported by isAncestorCommit, or
+// if walkLog is set, by the set of the commits of a single walk of go-git's
+// log from the head, as done before isAncestorCommit used IsAncestor.
+func AncestorTags(repo *git.Repository, opts Options, walkLog bool) ([]string, error) {
+	g, err := newGenerator(repo, opts)
+	if err != nil {
+		return nil, err
+	}
+	var reachable map[plumbing.Hash]bool
+	if walkLog {
+		reachable, err = logCommits(repo, g.head)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch tags: %w", err)
+	}
+
+	var names []string
+	err = tags.ForEach(func(tag *plumbing.Reference) error {
+		commit, err := g.getTagCommit(tag)
+		if err != nil {
+			return err
+		}
+		ancestor := reachable[commit.Hash]
+		if !walkLog {
+			ancestor, err = g.isAncestorCommit(commit)
+			if err != nil {
+				return err
+			}
+		}
+		if ancestor {
+			names = append(names, tag.Name().Short())
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// ReachableSets returns the sorted hashes of the commits reachable from each
+// revision, in the given order, as computed by reachableFrom if cached is
+// set, or by a walk of go-git's log otherwise.
+func ReachableSets(repo *git.Repository, revs []string, cached bool) ([][]string, error) {
+	g, err := newGenerator(repo, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make([][]string, 0, len(revs))
+	for _, rev := range revs {
+		hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve %q: %w", rev, err)
+		}
+		var reachable map[plumbing.Hash]bool
+		if cached {
+			var from *object.Commit
+			from, err = repo.CommitObject(*hash)
+			if err != nil {
+				return nil, fmt.Errorf("cannot retrieve commit %s: %w", hash, err)
+			}
+			reachable, err = g.reachableFrom(from)
+		} else {
+			reachable, err = logCommits(repo, *hash)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The set is only valid until the next call to reachableFrom.
+		sets = append(sets, sortedHashes(reachable))
+	}
+	return sets, nil
+}
+
+// TagRanges returns the hashes of the commits of each version of the
+// repository, oldest first, followed by the unreleased commits, as listed
+// by getCommitsInRange. If cached is set, a single generator computes all
+// the ranges, reusing the reachable sets across consecutive tags;
+// otherwise each range is computed by a new generator.
+func TagRanges(repo *git.Repository, opts Options, cached bool) ([][]string, error) {
+	g, err := newGenerator(repo, opts)
+	if err != nil {
+		return nil, err
+	}
+	semverTags, tagMap, err := g.getVersionTags()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]*plumbing.Reference, 0, len(semverTags)+1)
+	for _, ver := range semverTags {
+		tags = append(tags, tagMap[ver.String()])
+	}
+	tags = append(tags, nil)
+
+	var ranges [][]string
+	var prevTag *plumbing.Reference
+	for _, tag := range tags {
+		rg := g
+		if !cached {
+			rg, err = newGenerator(repo, opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		commits, err := rg.getCommitsInRange(prevTag, tag)
+		if err != nil {
+			return nil, err
+		}
+		hashes := make([]string, 0, len(commits))
+		for _, c := range commits {
+			hashes = append(hashes, c.Hash.String())
+		}
+		ranges = append(ranges, hashes)
+		prevTag = tag
+	}
+	return ranges, nil
+}
+
+// sortedHashes returns the hashes of the set, sorted.
+func sortedHashes(set map[plumbing.Hash]bool) []string {
+	hashes := make([]string, 0, len(set))
+	for hash := range set {
+		hashes = append(hashes, hash.String())
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// logCommits returns the set of the commits of go-git's log from the
+// commit, including itself.
+func logCommits(repo *git.Repository, from plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commitIter, err := repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch commits: %w", err)
+	}
+	reachable := make(map[plumbing.Hash]bool)
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		reachable[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot walk commits from %s: %w", from, err)
+	}
+	return reachable, nil
+}
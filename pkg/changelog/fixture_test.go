@@ -0,0 +1,263 @@
+package changelog_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/frgrisk/gotaglog/pkg/changelog"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// fixtureStart is the date of the first commit of a fixture repository.
+var fixtureStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// fixture is an in-memory git repository whose commits are dated one day
+// apart, starting at fixtureStart, so that the changelogs are reproducible.
+type fixture struct {
+	t    testing.TB
+	repo *git.Repository
+	wt   *git.Worktree
+	date time.Time
+}
+
+// newFixture returns an empty in-memory repository.
+func newFixture(t testing.TB) *fixture {
+	t.Helper()
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("cannot init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("cannot open worktree: %v", err)
+	}
+	return &fixture{t: t, repo: repo, wt: wt, date: fixtureStart}
+}
+
+// newFixtureAt returns an empty repository with a worktree in the directory,
+// for the options taking repository paths.
+func newFixtureAt(t testing.TB, dir string) *fixture {
+	t.Helper()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("cannot init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("cannot open worktree: %v", err)
+	}
+	return &fixture{t: t, repo: repo, wt: wt, date: fixtureStart}
+}
+
+// newLargeFixture returns a repository with the maintenance history of the
+// given number of minor versions.
+func newLargeFixture(t testing.TB, versions int) *fixture {
+	t.Helper()
+	f := newFixture(t)
+	f.maintenance(versions)
+	return f
+}
+
+// maintenance adds a main line of the given number of minor versions, where
+// every tenth one is patched on a maintenance branch of its own, merged back
+// into the main line one time out of two.
+func (f *fixture) maintenance(versions int) {
+	f.t.Helper()
+	for i := 1; i <= versions; i++ {
+		main := f.commit(fmt.Sprintf("feat: change %d", i))
+		f.tag(fmt.Sprintf("v0.%d.0", i))
+		if i%10 != 0 {
+			continue
+		}
+		f.checkout(fmt.Sprintf("maintenance-%d", i), main.String())
+		f.commit(fmt.Sprintf("fix: patch %d", i))
+		f.tag(fmt.Sprintf("v0.%d.1", i))
+		f.checkout(fmt.Sprintf("main-%d", i), main.String())
+		if i%20 == 0 {
+			f.merge(fmt.Sprintf("maintenance-%d", i), fmt.Sprintf("Merge branch 'maintenance-%d'", i))
+		}
+	}
+}
+
+// newBareRepository returns the path of a bare repository with a commit
+// "feat: <branch>" on each of the branches, and whose HEAD points to a
+// branch that does not exist, as in a mirror of a repository whose default
+// branch was renamed.
+func newBareRepository(t *testing.T, branches ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, true)
+	if err != nil {
+		t.Fatalf("cannot init repository: %v", err)
+	}
+
+	store := func(o object.Object) plumbing.Hash {
+		obj := repo.Storer.NewEncodedObject()
+		if err := o.Encode(obj); err != nil {
+			t.Fatalf("cannot encode object: %v", err)
+		}
+		hash, err := repo.Storer.SetEncodedObject(obj)
+		if err != nil {
+			t.Fatalf("cannot store object: %v", err)
+		}
+		return hash
+	}
+	tree := store(&object.Tree{})
+	sig := object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: fixtureStart}
+	for _, branch := range branches {
+		hash := store(&object.Commit{Author: sig, Committer: sig, Message: "feat: " + branch, TreeHash: tree})
+		ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), hash)
+		if err := repo.Storer.SetReference(ref); err != nil {
+			t.Fatalf("cannot create branch %q: %v", branch, err)
+		}
+	}
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("missing"))
+	if err := repo.Storer.SetReference(head); err != nil {
+		t.Fatalf("cannot set HEAD: %v", err)
+	}
+	return dir
+}
+
+// diamond adds two branches forked from v1.0.0, tagged v1.1.0 and v1.0.1,
+// merged together in v2.0.0 followed by an unreleased commit, and a third
+// branch tagged v9.0.0 that is never merged.
+func (f *fixture) diamond() {
+	f.t.Helper()
+	f.commit("feat: base")
+	f.tag("v1.0.0")
+	f.checkout("left", "HEAD")
+	f.commit("feat: left")
+	f.tag("v1.1.0")
+	f.checkout("right", "v1.0.0")
+	f.commit("fix: right")
+	f.tag("v1.0.1")
+	f.checkout("stray", "v1.0.0")
+	f.commit("feat: stray")
+	f.tag("v9.0.0")
+	f.checkout("release", "v1.1.0")
+	f.merge("right", "Merge branch 'right'")
+	f.tag("v2.0.0")
+	f.commit("docs: after")
+}
+
+// signature returns the signature of the next commit or tag, one day after
+// the previous one.
+func (f *fixture) signature() *object.Signature {
+	sig := &object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: f.date}
+	f.date = f.date.AddDate(0, 0, 1)
+	return sig
+}
+
+// commit adds an empty commit with the message on top of HEAD.
+func (f *fixture) commit(message string) plumbing.Hash {
+	f.t.Helper()
+	hash, err := f.wt.Commit(message, &git.CommitOptions{
+		Author:            f.signature(),
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		f.t.Fatalf("cannot commit %q: %v", message, err)
+	}
+	return hash
+}
+
+// commitFile writes the file, relative to the repository root, and commits
+// it with the message on top of HEAD.
+func (f *fixture) commitFile(path, message string) plumbing.Hash {
+	f.t.Helper()
+	file, err := f.wt.Filesystem.Create(path)
+	if err != nil {
+		f.t.Fatalf("cannot create %q: %v", path, err)
+	}
+	_, err = file.Write([]byte(message + "\n"))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		f.t.Fatalf("cannot write %q: %v", path, err)
+	}
+	if _, err := f.wt.Add(path); err != nil {
+		f.t.Fatalf("cannot add %q: %v", path, err)
+	}
+	return f.commit(message)
+}
+
+// merge adds a merge commit with the message on top of HEAD, whose second
+// parent is the revision.
+func (f *fixture) merge(rev, message string) plumbing.Hash {
+	f.t.Helper()
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatalf("cannot resolve HEAD: %v", err)
+	}
+	hash, err := f.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		f.t.Fatalf("cannot resolve %q: %v", rev, err)
+	}
+	merge, err := f.wt.Commit(message, &git.CommitOptions{
+		Author:            f.signature(),
+		Parents:           []plumbing.Hash{head.Hash(), *hash},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		f.t.Fatalf("cannot commit %q: %v", message, err)
+	}
+	return merge
+}
+
+// tag adds a lightweight tag to HEAD.
+func (f *fixture) tag(name string) {
+	f.t.Helper()
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatalf("cannot resolve HEAD: %v", err)
+	}
+	if _, err := f.repo.CreateTag(name, head.Hash(), nil); err != nil {
+		f.t.Fatalf("cannot create tag %q: %v", name, err)
+	}
+}
+
+// annotatedTag adds an annotated tag with the message to HEAD.
+func (f *fixture) annotatedTag(name, message string) {
+	f.t.Helper()
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatalf("cannot resolve HEAD: %v", err)
+	}
+	_, err = f.repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{Tagger: f.signature(), Message: message})
+	if err != nil {
+		f.t.Fatalf("cannot create tag %q: %v", name, err)
+	}
+}
+
+// checkout moves HEAD to a new branch starting at the revision.
+func (f *fixture) checkout(branch, rev string) {
+	f.t.Helper()
+	hash, err := f.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		f.t.Fatalf("cannot resolve %q: %v", rev, err)
+	}
+	err = f.wt.Checkout(&git.CheckoutOptions{Hash: *hash, Branch: plumbing.NewBranchReferenceName(branch), Create: true})
+	if err != nil {
+		f.t.Fatalf("cannot check out %q: %v", branch, err)
+	}
+}
+
+// render returns the changelog of the repository rendered as Markdown.
+func (f *fixture) render(opts changelog.Options) string {
+	f.t.Helper()
+	cl, err := changelog.BuildRepository(f.repo, opts)
+	if err != nil {
+		f.t.Fatalf("cannot build changelog: %v", err)
+	}
+	md, err := cl.Render()
+	if err != nil {
+		f.t.Fatalf("cannot render changelog: %v", err)
+	}
+	return md
+}
@@ -0,0 +1,52 @@
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+// ToHTML converts the Markdown changelog to HTML. Headers get the same
+// anchor ids as on GitHub, so that the links of the table of contents keep
+// working.
+func ToHTML(md string) (string, error) {
+	converter := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+	ctx := parser.NewContext(parser.WithIDs(&headerIDs{used: make(map[string]bool)}))
+
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(md), &buf, parser.WithContext(ctx)); err != nil {
+		return "", fmt.Errorf("cannot convert changelog to HTML: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// headerIDs generates the header ids with headerAnchor, suffixing
+// duplicates with a counter as GitHub does.
+type headerIDs struct {
+	used map[string]bool
+}
+
+func (ids *headerIDs) Generate(value []byte, _ ast.NodeKind) []byte {
+	anchor := headerAnchor(string(value))
+	if anchor == "" {
+		anchor = "heading"
+	}
+	id := anchor
+	for i := 1; ids.used[id]; i++ {
+		id = anchor + "-" + strconv.Itoa(i)
+	}
+	ids.used[id] = true
+	return []byte(id)
+}
+
+func (ids *headerIDs) Put(value []byte) {
+	ids.used[string(value)] = true
+}
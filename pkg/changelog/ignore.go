@@ -0,0 +1,66 @@
+package changelog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// IgnoreFile is the name of the file at the root of the repository listing
+// the regexes of the commit titles to leave out, one per line.
+const IgnoreFile = ".gotaglogignore"
+
+// loadIgnorePatterns compiles the patterns of the ignore file in the
+// worktree of the repository. A missing file or a bare repository yields
+// no patterns. Blank lines and lines starting with "#" are skipped.
+func loadIgnorePatterns(repo *git.Repository) ([]*regexp.Regexp, error) {
+	wt, err := repo.Worktree()
+	if errors.Is(err, git.ErrIsBareRepository) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open worktree: %w", err)
+	}
+	f, err := wt.Filesystem.Open(IgnoreFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", IgnoreFile, err)
+	}
+	defer f.Close()
+
+	var patterns []*regexp.Regexp
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q on line %d of %s: %w", line, n, IgnoreFile, err)
+		}
+		patterns = append(patterns, re)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", IgnoreFile, err)
+	}
+	return patterns, nil
+}
+
+// isIgnoredTitle reports whether the commit title matches one of the
+// patterns of the ignore file.
+func (g *generator) isIgnoredTitle(title string) bool {
+	for _, re := range g.ignorePatterns {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
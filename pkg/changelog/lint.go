@@ -0,0 +1,117 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// lintPrefixRegex matches the part of a conventional commit title before
+// the colon: the type, an optional scope and the breaking change marker.
+var lintPrefixRegex = regexp.MustCompile(`^[^\s()!:]+(\([^()]*\))?!?$`)
+
+// Violation is a line of a commit message that does not follow the
+// conventional commit format.
+type Violation struct {
+	// Hash is the full hash of the commit.
+	Hash string
+	// Line is the number of the line of the commit message, starting at 1
+	// for the title.
+	Line int
+	// Text is the text of the line.
+	Text string
+	// Reason describes the violation, such as "missing colon after the
+	// type".
+	Reason string
+}
+
+// String returns the violation as "hash:line: reason: text", with the
+// abbreviated hash.
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d: %s: %q", v.Hash[:7], v.Line, v.Reason, v.Text)
+}
+
+// Lint returns the violations of the conventional commit format in the
+// messages of the commits reachable from ToRef but not from FromRef, in the
+// order of the history. A title is valid if it matches one of the commit
+// groups, including the skipped ones, or the ignore file. The other
+// options selecting commits, such as Paths, apply too.
+func Lint(opts Options) ([]Violation, error) {
+	if len(opts.RepoPaths) > 1 {
+		return nil, errors.New("lint requires a single repository")
+	}
+	g, err := openGenerator(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to *plumbing.Reference
+	if opts.FromRef != "" {
+		from, err = g.resolveRef(opts.FromRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.ToRef != "" {
+		to, err = g.resolveRef(opts.ToRef)
+		if err != nil {
+			return nil, err
+		}
+	} else if g.head.IsZero() {
+		// The repository has no commits yet.
+		return nil, nil
+	}
+
+	commits, err := g.getCommitsInRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for _, c := range commits {
+		lines := strings.Split(strings.TrimRight(c.Message, "\n"), "\n")
+		title := g.commitTitle(lines[0])
+		if g.isIgnoredTitle(title) {
+			continue
+		}
+		if reason := g.titleViolation(title); reason != "" {
+			violations = append(violations, Violation{Hash: c.Hash.String(), Line: 1, Text: lines[0], Reason: reason})
+		}
+		if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+			violations = append(violations, Violation{Hash: c.Hash.String(), Line: 2, Text: lines[1], Reason: "missing blank line after the title"})
+		}
+	}
+	return violations, nil
+}
+
+// titleViolation describes why the commit title does not follow the
+// conventional commit format, or returns an empty string if it does.
+func (g *generator) titleViolation(title string) string {
+	if strings.TrimSpace(title) == "" {
+		return "empty title"
+	}
+	prefix, description, found := strings.Cut(title, ":")
+	if !found {
+		return "missing colon after the type"
+	}
+	if prefix == "" || strings.HasPrefix(prefix, "(") || strings.HasPrefix(prefix, "!") {
+		return "missing type"
+	}
+	if !lintPrefixRegex.MatchString(prefix) {
+		return fmt.Sprintf("bad type or scope %q", prefix)
+	}
+	if strings.TrimSpace(description) == "" {
+		return "empty description"
+	}
+	if !strings.HasPrefix(description, " ") {
+		return "missing space after the colon"
+	}
+	for _, group := range g.groups {
+		if group.re.MatchString(title) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("bad type %q", commitType(title))
+}
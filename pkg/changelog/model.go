@@ -0,0 +1,245 @@
+package changelog
+
+import (
+	"fmt"
+	"time"
+)
+
+// Changelog is the data model of a changelog, rendered by the changelog
+// template or encoded as JSON.
+type Changelog struct {
+	// Versions are the versions of the changelog, including the unreleased
+	// changes, in the configured order.
+	Versions []*Version `json:"versions"`
+	// Options are the options the changelog was generated with, with their
+	// defaults applied.
+	Options Options `json:"-"`
+	// RepoURL is the web URL of the repository, from RepoURL or the origin
+	// remote, if any. It is empty for the changelogs of several
+	// repositories.
+	RepoURL string `json:"-"`
+	// Skipped are the commits left out of the changelog, in the order in
+	// which they were classified, if ReportSkipped is set.
+	Skipped []*SkippedCommit `json:"skipped,omitempty"`
+}
+
+// HasLinks reports whether any version has a compare URL.
+func (c *Changelog) HasLinks() bool {
+	for _, v := range c.Versions {
+		if v.CompareURL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SkippedCommit is a commit left out of the changelog.
+type SkippedCommit struct {
+	// Hash is the full hash of the commit.
+	Hash string `json:"hash"`
+	// Title is the title of the commit.
+	Title string `json:"title"`
+	// Reason is the reason the commit was left out, one of the SkipReason
+	// constants, followed by the regex of the group for
+	// SkipReasonSkipGroup.
+	Reason string `json:"reason"`
+}
+
+// Version is a release, or the unreleased changes, of a changelog.
+type Version struct {
+	// Label is the version with the tag prefix, or the unreleased tag,
+	// preceded by the repository name when combining several repositories.
+	Label string `json:"label"`
+	// Repo is the name of the repository of the version when combining
+	// several repositories, or an empty string.
+	Repo string `json:"repo,omitempty"`
+	// Component is the component of the version when combining the tags
+	// of several components matched by TagPattern, or an empty string.
+	Component string `json:"component,omitempty"`
+	// Tag is the name of the tag, or an empty string for the unreleased
+	// changes.
+	Tag string `json:"tag,omitempty"`
+	// Date is the date of the tagged commit, or of the annotated tag
+	// according to DateSource, the current date for the versioned
+	// unreleased changes, or the zero time for the unversioned unreleased
+	// changes.
+	Date time.Time `json:"date"`
+	// Unreleased is set for the unreleased changes.
+	Unreleased bool `json:"unreleased"`
+	// DateRange is the range of the dates of the unreleased commits, such
+	// as "2024-01-01 — 2024-02-15", or their date if they share it, if
+	// DateRange is set.
+	DateRange string `json:"date_range,omitempty"`
+	// Header is the text of the version header, such as
+	// "[1.2.0] - 2024-01-31", or an empty string for the commits between
+	// FromRef and ToRef.
+	Header string `json:"header"`
+	// Anchor is the anchor of the version header as generated by GitHub.
+	Anchor string `json:"anchor"`
+	// Message is the message of the annotated tag, if IncludeTagMessage is
+	// set.
+	Message string `json:"message,omitempty"`
+	// CompareURL is the URL comparing the version to the previous one, if
+	// CompareLinks is set and the repository URL is known.
+	CompareURL string `json:"compare_url,omitempty"`
+	// CommitCount is the number of commits listed in the version, or of
+	// all the commits not skipped if CountAll is set.
+	CommitCount int `json:"commit_count"`
+	// Stats are the diff stats of the commits of the version, if Stats is
+	// set.
+	Stats *Stats `json:"stats,omitempty"`
+	// Breaking is the group of the breaking changes, or nil if there are
+	// none.
+	Breaking *Group `json:"breaking,omitempty"`
+	// Groups are the groups of commits with at least one commit, or all
+	// the groups if ShowEmptyGroups is set, in the configured order.
+	Groups []*Group `json:"groups"`
+	// Summary is the number of commits of each type and of breaking
+	// changes, such as "3 feat, 5 fix, **1 breaking**", if Compact is set.
+	Summary string `json:"summary,omitempty"`
+}
+
+// commitDateRange returns the earliest and latest dates of the commits
+// listed in the version, or zero times if there are none.
+func (v *Version) commitDateRange() (first, last time.Time) {
+	groups := v.Groups
+	if v.Breaking != nil {
+		groups = append([]*Group{v.Breaking}, groups...)
+	}
+	for _, group := range groups {
+		for _, c := range group.Commits {
+			if first.IsZero() || c.Date.Before(first) {
+				first = c.Date
+			}
+			if last.IsZero() || c.Date.After(last) {
+				last = c.Date
+			}
+		}
+	}
+	return first, last
+}
+
+// hasChanges reports whether the version has any commits.
+func (v *Version) hasChanges() bool {
+	if v.Breaking != nil {
+		return true
+	}
+	for _, group := range v.Groups {
+		if len(group.Commits) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats are the diff stats of the commits of a version.
+type Stats struct {
+	// FilesChanged is the number of distinct files changed by the commits.
+	FilesChanged int `json:"files_changed"`
+	// Insertions and Deletions are the numbers of lines added and removed
+	// by the commits.
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// String returns the stats as git does, such as "3 files changed,
+// 10 insertions(+), 2 deletions(-)".
+func (s *Stats) String() string {
+	return fmt.Sprintf("%d %s changed, %d %s(+), %d %s(-)",
+		s.FilesChanged, plural(s.FilesChanged, "file", "files"),
+		s.Insertions, plural(s.Insertions, "insertion", "insertions"),
+		s.Deletions, plural(s.Deletions, "deletion", "deletions"))
+}
+
+// plural returns singular if n is 1, or else pluralForm.
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
+
+// Group is a section of a version listing the commits of a commit group.
+type Group struct {
+	// Title is the rendered title of the group.
+	Title string `json:"title"`
+	// Commits are the commits of the group, in the configured order.
+	Commits []*Commit `json:"commits"`
+	// Scopes are the commits of the group by scope, sorted by name with
+	// the commits without a scope last, if GroupByScope is set.
+	Scopes []*Scope `json:"scopes,omitempty"`
+	// Omitted is the number of commits of the group left out beyond
+	// MaxPerGroup.
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// Scope is a subsection of a group listing the commits of a scope.
+type Scope struct {
+	// Name is the lowercased scope, or "Other" for the commits without a
+	// scope.
+	Name string `json:"name"`
+	// Commits are the commits of the scope, in the configured order.
+	Commits []*Commit `json:"commits"`
+}
+
+// Commit is a conventional commit of a changelog.
+type Commit struct {
+	// Hash is the full hash of the commit.
+	Hash string `json:"hash"`
+	// URL is the web URL of the commit, or an empty string if the
+	// repository URL is unknown.
+	URL string `json:"url,omitempty"`
+	// Type is the conventional commit type, such as "feat".
+	Type string `json:"type"`
+	// Scope is the lowercased scope, or an empty string.
+	Scope string `json:"scope,omitempty"`
+	// Description is the title without the type and scope, capitalized.
+	Description string `json:"description"`
+	// Body are the lines of the commit body, without the breaking change
+	// footers.
+	Body []string `json:"body,omitempty"`
+	// PR is the number of the pull request referenced in the title, if
+	// PRURL is set.
+	PR string `json:"pr,omitempty"`
+	// Issues are the numbers of the issues referenced in the body.
+	Issues []string `json:"issues,omitempty"`
+	// Breaking is set for breaking changes.
+	Breaking bool `json:"breaking"`
+	// BreakingNote is the text of the breaking change footers of the
+	// commit, if BreakingNotes is set.
+	BreakingNote string `json:"breaking_note,omitempty"`
+	// Author is the name of the commit author.
+	Author string `json:"author"`
+	// Authors are the author and co-authors of the commit, if ShowAuthors
+	// is set.
+	Authors []*Author `json:"authors,omitempty"`
+	// Reviewers are the reviewers of the "Reviewed-by" trailers of the
+	// commit, if ShowReviewers is set.
+	Reviewers []*Author `json:"reviewers,omitempty"`
+	// Date is the author or committer date of the commit, according to
+	// DateSource.
+	Date time.Time `json:"date"`
+	// Signed is set if the commit has a PGP signature.
+	Signed bool `json:"signed,omitempty"`
+	// Verified is set if VerifySignatures is set and the signature of the
+	// commit is made by a key of the keyring.
+	Verified bool `json:"verified,omitempty"`
+	// Entry is the Markdown list entry of the commit as rendered by the
+	// default template, without the list marker and the type of breaking
+	// changes.
+	Entry string `json:"entry"`
+}
+
+// Author is an author or co-author of a commit.
+type Author struct {
+	// Name is the name of the author.
+	Name string `json:"name"`
+	// Email is the email of the author.
+	Email string `json:"email"`
+	// Handle is the handle of the author, without the "@", or an empty
+	// string if it is unknown.
+	Handle string `json:"handle,omitempty"`
+	// URL is the profile URL of the author, or an empty string if it is
+	// unknown.
+	URL string `json:"url,omitempty"`
+}
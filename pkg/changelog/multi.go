@@ -0,0 +1,77 @@
+package changelog
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// buildMulti returns the changelog combining the repositories of
+// opts.RepoPaths. The versions of all the repositories are interleaved by
+// date, with the unreleased changes at the newest end, and their labels are
+// preceded by the name of their repository.
+func buildMulti(opts Options) (*Changelog, error) {
+	cl := &Changelog{}
+	var first *generator
+	for _, path := range opts.RepoPaths {
+		repoOpts := opts
+		repoOpts.RepoPath = path
+		repoOpts.RepoPaths = nil
+
+		g, err := openGenerator(repoOpts)
+		if err != nil {
+			return nil, fmt.Errorf("repository %q: %w", path, err)
+		}
+		repoChangelog, err := g.buildRepository()
+		if err != nil {
+			return nil, fmt.Errorf("repository %q: %w", path, err)
+		}
+
+		name := repoName(path)
+		for _, version := range repoChangelog.Versions {
+			version.Repo = name
+			version.Label = name + " " + version.Label
+			g.setHeader(version)
+		}
+		cl.Versions = append(cl.Versions, repoChangelog.Versions...)
+		cl.Skipped = append(cl.Skipped, repoChangelog.Skipped...)
+
+		if first == nil {
+			first = g
+			cl.Options = repoChangelog.Options
+		}
+	}
+
+	sortVersions(cl.Versions, first.opts.Order)
+	return cl, nil
+}
+
+// sortVersions sorts the versions of several changelogs by date in the
+// order, with the unreleased changes at the newest end.
+func sortVersions(versions []*Version, order string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		if order == OrderAsc {
+			return isNewer(versions[j], versions[i])
+		}
+		return isNewer(versions[i], versions[j])
+	})
+}
+
+// isNewer reports whether the version a comes after the version b. The
+// unreleased changes come after all releases.
+func isNewer(a, b *Version) bool {
+	if a.Unreleased != b.Unreleased {
+		return a.Unreleased
+	}
+	return a.Date.After(b.Date)
+}
+
+// repoName returns the name of the repository at the path: the name of its
+// directory, without the ".git" suffix of bare repositories.
+func repoName(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".git")
+}
@@ -0,0 +1,93 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// getRepoURL returns the web URL of the repository. The given repoURL takes
+// precedence; otherwise it is derived from the "origin" remote. An empty
+// string is returned if no URL can be determined.
+func getRepoURL(repo *git.Repository, repoURL string) string {
+	if repoURL != "" {
+		return strings.TrimSuffix(repoURL, "/")
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return ""
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return ""
+	}
+
+	return remoteToWebURL(urls[0])
+}
+
+// remoteToWebURL converts a git remote URL, such as
+// git@github.com:org/repo.git, to the web URL of the repository, such as
+// https://github.com/org/repo.
+func remoteToWebURL(remoteURL string) string {
+	endpoint, err := transport.NewEndpoint(remoteURL)
+	if err != nil {
+		return ""
+	}
+
+	host := endpoint.Host
+	scheme := "https"
+	switch endpoint.Protocol {
+	case "http", "https":
+		scheme = endpoint.Protocol
+		if endpoint.Port != 0 {
+			host = fmt.Sprintf("%s:%d", host, endpoint.Port)
+		}
+	case "ssh", "git":
+	default:
+		return ""
+	}
+
+	path := strings.TrimSuffix(strings.Trim(endpoint.Path, "/"), ".git")
+	if host == "" || path == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s://%s/%s", scheme, host, path)
+}
+
+// getCommitURL returns the web URL of the commit with the given hash, or
+// an empty string if the repository URL is unknown.
+func (g *generator) getCommitURL(hash string) string {
+	if g.repoURL == "" {
+		return ""
+	}
+	path := strings.ReplaceAll(g.opts.CommitPath, "{hash}", hash)
+	return g.repoURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// getCompareURL returns the URL comparing the from and to revisions. If
+// from is empty, the URL points to the release page of the to tag instead.
+func (g *generator) getCompareURL(from, to string) string {
+	var path string
+	if from == "" {
+		path = strings.ReplaceAll(g.opts.ReleasePath, "{tag}", to)
+	} else {
+		path = strings.NewReplacer("{from}", from, "{to}", to).Replace(g.opts.ComparePath)
+	}
+	return g.repoURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// getIssueURL returns the URL of the issue with the given number, or an
+// empty string if neither an issue URL nor the repository URL is known.
+func (g *generator) getIssueURL(id string) string {
+	if g.opts.IssueURL != "" {
+		return strings.ReplaceAll(g.opts.IssueURL, "{id}", id)
+	}
+	if g.repoURL == "" {
+		return ""
+	}
+	return g.repoURL + "/issues/" + id
+}
@@ -0,0 +1,66 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	log "github.com/sirupsen/logrus"
+)
+
+// revertTitleRegex matches the title of a revert commit, either
+// conventional, such as `revert: feat: add X`, or generated by git, such as
+// `Revert "feat: add X"`, and captures the reverted title.
+var revertTitleRegex = regexp.MustCompile(`^(?:revert(?:\([^)]*\))?!?:\s*(.+?)|Revert "(.+)")\s*$`)
+
+// revertHashRegex matches the reference to the reverted commit in the body
+// of a revert commit, such as "This reverts commit 1a2b3c4." or
+// "Refs: 1a2b3c4", and captures its hash.
+var revertHashRegex = regexp.MustCompile(`(?im)^(?:This reverts commit|Refs:)\s+([0-9a-f]{7,40})\b`)
+
+// foldReverts returns the commits without the revert commits whose reverted
+// commit is among the commits, and without the reverted commits. The
+// reverted commit is found by the hash referenced in the body of the revert
+// commit, or else by its title.
+func foldReverts(commits []*object.Commit) []*object.Commit {
+	folded := make(map[*object.Commit]bool)
+	// The oldest commits come last, and a commit can only revert an older
+	// one.
+	for i := len(commits) - 1; i >= 0; i-- {
+		revert := commits[i]
+		match := revertTitleRegex.FindStringSubmatch(strings.Split(revert.Message, "\n")[0])
+		if match == nil {
+			continue
+		}
+		revertedTitle := match[1] + match[2]
+		revertedHash := ""
+		if m := revertHashRegex.FindStringSubmatch(revert.Message); m != nil {
+			revertedHash = m[1]
+		}
+
+		for _, reverted := range commits[i+1:] {
+			if folded[reverted] {
+				continue
+			}
+			title := strings.Split(reverted.Message, "\n")[0]
+			if (revertedHash != "" && strings.HasPrefix(reverted.Hash.String(), revertedHash)) ||
+				(revertedHash == "" && title == revertedTitle) {
+				log.Debugf("Folding commit %s reverted by %s", reverted.Hash, revert.Hash)
+				folded[revert] = true
+				folded[reverted] = true
+				break
+			}
+		}
+	}
+
+	if len(folded) == 0 {
+		return commits
+	}
+	kept := make([]*object.Commit, 0, len(commits)-len(folded))
+	for _, c := range commits {
+		if !folded[c] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,38 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// readKeyring parses the armored PGP public keyring.
+func readKeyring(armored string) (openpgp.EntityList, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring: %w", err)
+	}
+	return keyring, nil
+}
+
+// verifySignature reports whether the commit has a PGP signature made by a
+// key of the keyring. It is the same check as object.Commit.Verify, without
+// parsing the keyring for each commit.
+func (g *generator) verifySignature(c *object.Commit) bool {
+	if c.PGPSignature == "" {
+		return false
+	}
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return false
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return false
+	}
+	_, err = openpgp.CheckArmoredDetachedSignature(g.keyring, reader, strings.NewReader(c.PGPSignature), nil)
+	return err == nil
+}
@@ -0,0 +1,27 @@
+package changelog
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// diffStats returns the diff stats of the commits, each compared to its
+// first parent, or to an empty tree for the root commit.
+func diffStats(commits []*object.Commit) (*Stats, error) {
+	stats := &Stats{}
+	files := make(map[string]bool)
+	for _, c := range commits {
+		fileStats, err := c.Stats()
+		if err != nil {
+			return nil, fmt.Errorf("cannot compute stats of commit %s: %w", c.Hash, err)
+		}
+		for _, fs := range fileStats {
+			files[fs.Name] = true
+			stats.Insertions += fs.Addition
+			stats.Deletions += fs.Deletion
+		}
+	}
+	stats.FilesChanged = len(files)
+	return stats, nil
+}
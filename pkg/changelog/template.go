@@ -0,0 +1,45 @@
+package changelog
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplate is the text/template rendering the changelog as
+// Markdown. It receives a *Changelog.
+//
+//go:embed default.tmpl
+var DefaultTemplate string
+
+// parseTemplate parses the changelog template, or the default template if
+// text is empty.
+func parseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("changelog").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render renders the changelog with the template of its options.
+func (c *Changelog) Render() (string, error) {
+	tmpl, err := parseTemplate(c.Options.Template)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, c); err != nil {
+		return "", fmt.Errorf("cannot render template: %w", err)
+	}
+	if c.Options.NoTitle {
+		// Without the title, the changelog starts with the blank lines
+		// separating it from the first section.
+		return strings.TrimLeft(out.String(), "\n"), nil
+	}
+	return out.String(), nil
+}
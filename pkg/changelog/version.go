@@ -0,0 +1,81 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// NextVersion returns the version following the latest version reachable
+// from the head commit, bumped according to the unreleased changes: a
+// breaking change bumps the major version, a feature the minor version, and
+// any other change the patch version. Without any version tag, the version
+// is bumped from 0.0.0.
+func NextVersion(opts Options) (string, error) {
+	if len(opts.RepoPaths) > 1 {
+		return "", errors.New("next version requires a single repository")
+	}
+	g, err := openGenerator(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if g.head.IsZero() {
+		return "", errors.New("repository has no commits")
+	}
+	if g.tagPattern != nil && g.component == "" {
+		components, err := g.components()
+		if err != nil {
+			return "", err
+		}
+		if len(components) > 1 {
+			return "", errors.New("next version requires the tags of a single component")
+		}
+		if len(components) == 1 {
+			g.component = components[0]
+		}
+	}
+
+	semverTags, tagMap, err := g.getVersionTags()
+	if err != nil {
+		return "", err
+	}
+
+	latest := semver.MustParse("0.0.0")
+	latestName := "the first commit"
+	var latestTag *plumbing.Reference
+	if len(semverTags) > 0 {
+		latest = semverTags[len(semverTags)-1]
+		latestTag = tagMap[latest.String()]
+		latestName = latestTag.Name().Short()
+	}
+
+	changes, err := g.getTagEntryDetails(latestTag, nil)
+	if err != nil {
+		return "", err
+	}
+	if !changes.hasChanges() {
+		return "", fmt.Errorf("no unreleased changes since %s", latestName)
+	}
+
+	next := bumpVersion(latest, changes)
+	return g.versionLabel(&next), nil
+}
+
+// bumpVersion returns the version following latest according to the
+// changes, as described by NextVersion.
+func bumpVersion(latest *semver.Version, changes *Version) semver.Version {
+	if changes.Breaking != nil {
+		return latest.IncMajor()
+	}
+	for _, group := range changes.Groups {
+		for _, c := range group.Commits {
+			if c.Type == "feat" {
+				return latest.IncMinor()
+			}
+		}
+	}
+	return latest.IncPatch()
+}